@@ -1,12 +1,22 @@
 package main
 
 import (
+	"bufio"
 	"context"
-	"log"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 	application "scraping-airbnb/cmd/scraper"
 	"scraping-airbnb/config"
+	"scraping-airbnb/scraper"
+	"scraping-airbnb/utils"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -17,7 +27,7 @@ func init() {
 	// load .env file from project root
 	envPath := filepath.Join(".", ".env")
 	if err := godotenv.Load(envPath); err != nil {
-		log.Printf("warning: .env file not found at %s; using environment variables", envPath)
+		slog.Warn(".env file not found; using environment variables", "path", envPath)
 	}
 }
 
@@ -27,17 +37,287 @@ func main() {
 	// load config
 	cfg := config.Default()
 
+	// SCRAPER_PRODUCT_WORKERS sets the product worker pool size; -workers overrides it
+	if v := os.Getenv("SCRAPER_PRODUCT_WORKERS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			slog.Error("invalid SCRAPER_PRODUCT_WORKERS", "value", v, "error", err)
+			os.Exit(1)
+		}
+		cfg.Concurrency.ProductWorkers = n
+	}
+
+	flag.IntVar(&cfg.Concurrency.ProductWorkers, "workers", cfg.Concurrency.ProductWorkers,
+		"product pages scraped in parallel, one Chrome tab each")
+	flag.IntVar(&cfg.Scraper.MaxProperties, "max-properties", cfg.Scraper.MaxProperties,
+		"max listings to scrape per run (0 = unlimited)")
+	flag.IntVar(&cfg.Scraper.MaxPerLocation, "max-per-location", cfg.Scraper.MaxPerLocation,
+		"collect at most this many listings from each location (0 = unlimited)")
+	flag.Func("min-price", "drop listings below this nightly price", parseFloat32(&cfg.Scraper.MinPrice))
+	flag.Func("max-price", "drop listings above this nightly price", parseFloat32(&cfg.Scraper.MaxPrice))
+	flag.BoolVar(&cfg.Scraper.IncludeUnpriced, "include-unpriced", cfg.Scraper.IncludeUnpriced,
+		"keep listings whose price could not be parsed")
+	flag.Func("min-rating", "drop listings rated below this", parseFloat32(&cfg.Scraper.MinRating))
+	flag.BoolVar(&cfg.Scraper.KeepUnrated, "keep-unrated", cfg.Scraper.KeepUnrated,
+		"keep listings that have no rating yet")
+	flag.StringVar(&cfg.Scraper.Locale, "locale", cfg.Scraper.Locale,
+		"language forced on every page (empty = let the site decide)")
+	flag.StringVar(&cfg.Scraper.Currency, "currency", cfg.Scraper.Currency,
+		"currency forced on every page (empty = let the site decide)")
+	flag.BoolVar(&cfg.Scraper.Refresh, "refresh", cfg.Scraper.Refresh,
+		"re-scrape listings that are already stored")
+	flag.Func("seed-urls", "comma-separated search URLs crawled if the homepage yields no location links",
+		func(v string) error {
+			cfg.Scraper.SeedSearchURLs = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+			return nil
+		})
+	flag.Func("delay-distribution", "how random delays spread between their min and max: uniform or normal",
+		func(v string) error {
+			if v != config.DelayUniform && v != config.DelayNormal {
+				return fmt.Errorf("want %q or %q", config.DelayUniform, config.DelayNormal)
+			}
+			cfg.Stealth.DelayDistribution = v
+			return nil
+		})
+	flag.Func("device", "user agents tabs present: desktop, mobile (phone viewport) or mixed",
+		func(v string) error {
+			switch v {
+			case config.DeviceDesktop, config.DeviceMobile, config.DeviceMixed:
+				cfg.Stealth.Device = v
+				return nil
+			}
+			return fmt.Errorf("want %q, %q or %q", config.DeviceDesktop, config.DeviceMobile, config.DeviceMixed)
+		})
+	flag.BoolVar(&cfg.Stealth.AdaptiveRateLimit, "adaptive-rate-limit", cfg.Stealth.AdaptiveRateLimit,
+		"halve the request rate on captchas and failed pages, recovering after successes")
+	flag.BoolVar(&cfg.Stealth.FingerprintMasking, "mask-fingerprint", cfg.Stealth.FingerprintMasking,
+		"hide headless-Chrome tells such as navigator.webdriver from page scripts")
+	flag.BoolVar(&cfg.Scraper.StopAtKnownPage, "stop-at-known", cfg.Scraper.StopAtKnownPage,
+		"stop paginating a search once a whole page holds only stored listings")
+	flag.DurationVar(&cfg.Scraper.RefreshAfter, "refresh-after", cfg.Scraper.RefreshAfter,
+		"also re-scrape stored listings last scraped longer ago than this (e.g. 24h)")
+	flag.Func("required-fields", "comma-separated fields that must not be empty (title,price,nights,rating,location)",
+		func(v string) error {
+			cfg.Scraper.RequiredFields = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+			return nil
+		})
+	flag.Func("block-resources", "comma-separated resource types not to load (image,media,font,stylesheet,script,ping,other)",
+		func(v string) error {
+			types := strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+			if err := scraper.ValidateBlockResources(types); err != nil {
+				return err
+			}
+			cfg.Browser.BlockResources = types
+			return nil
+		})
+	flag.IntVar(&cfg.Scraper.MaxReviews, "max-reviews", cfg.Scraper.MaxReviews,
+		"collect up to this many review texts per listing (0 = none)")
+	flag.Func("date-ranges", "also price each listing for these stays, e.g. 2025-07-04:2025-07-06,2025-07-11:2025-07-13",
+		func(v string) error {
+			ranges, err := config.ParseDateRanges(v)
+			if err != nil {
+				return err
+			}
+			cfg.Scraper.DateRanges = ranges
+			return nil
+		})
+	flag.IntVar(&cfg.Scraper.MaxDescriptionLength, "max-description-length", cfg.Scraper.MaxDescriptionLength,
+		"truncate descriptions in CSV output to this many characters (0 = full text)")
+	flag.DurationVar(&cfg.Timing.TotalRunTimeout, "timeout", cfg.Timing.TotalRunTimeout,
+		"stop the whole run after this long, saving what was scraped (e.g. 30m; 0 = unlimited)")
+	flag.Int64Var(&cfg.Retry.GlobalBudget, "retry-budget", cfg.Retry.GlobalBudget,
+		"total retries allowed per run across all pages (0 = unlimited)")
+	flag.Float64Var(&cfg.Scraper.MaxFailureRatio, "max-failure-ratio", cfg.Scraper.MaxFailureRatio,
+		"fail the run when more than this share of urls fail (0 = never)")
+	flag.BoolVar(&cfg.Output.Progress, "progress", cfg.Output.Progress,
+		"print a \"scraped n/total\" line to stdout as listings are extracted")
+	flag.DurationVar(&cfg.Debug.TargetCountInterval, "debug-tabs", cfg.Debug.TargetCountInterval,
+		"log the number of open browser tabs this often to spot leaks, e.g. 30s (0 = off)")
+	validateURL := flag.String("validate-selectors", "",
+		"check every extractor against this listing URL and exit without saving")
+	searchURL := flag.String("search-url", "",
+		"scrape one search results page (e.g. \"Homes in Paris\") instead of starting at the homepage")
+	urlsFile := flag.String("urls-file", "",
+		"newline-delimited file of listing URLs to scrape directly, skipping discovery (\"-\" reads stdin)")
+	flag.StringVar(&cfg.Output.Format, "output", os.Getenv("OUTPUT_FORMAT"),
+		"where to save results, e.g. postgres, csv or postgres,csv (overrides OUTPUT_FORMAT)")
+	flag.StringVar(&cfg.Output.FailuresPath, "failures-file", os.Getenv("FAILURES_FILE"),
+		"write listings that failed to this JSONL file (url, error, attempts); pass it back with -urls-file to retry them")
+	flag.IntVar(&cfg.Output.SaveEvery, "save-every", cfg.Output.SaveEvery,
+		"save listings in batches of this many as they are scraped (0 = save once at the end)")
+	collectOnly := flag.Bool("collect-urls-only", false,
+		"discover listings and save their urls to the output (csv, json or stdout) without extracting them")
+	ignoreRobots := flag.Bool("ignore-robots", false,
+		"do not enforce robots.txt (only use when you have permission to crawl)")
+	flag.Parse()
+	if *ignoreRobots {
+		cfg.Stealth.RespectRobots = false
+	}
+	cfg.Output.InsightsJSONPath = os.Getenv("INSIGHTS_JSON_PATH")
+
+	// DEBUG_DUMP_HTML=failed dumps the DOM of pages that fail to extract; =all dumps every product page
+	switch os.Getenv("DEBUG_DUMP_HTML") {
+	case "failed":
+		cfg.Debug.DumpHTMLOnFailure = true
+	case "all":
+		cfg.Debug.DumpHTMLOnFailure = true
+		cfg.Debug.DumpHTMLAlways = true
+	}
+
+	// LOG_FORMAT=json|text, LOG_LEVEL=debug|info|warn|error
+	if v := os.Getenv("LOG_FORMAT"); v != "" {
+		cfg.Log.Format = v
+	}
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		cfg.Log.Level = v
+	}
+	// METRICS_ADDR (e.g. ":2112") enables the Prometheus /metrics endpoint
+	if v := os.Getenv("METRICS_ADDR"); v != "" {
+		cfg.Metrics.Enabled = true
+		cfg.Metrics.Addr = v
+	}
+	// SELECTORS_FILE overrides product-page CSS selectors without a rebuild
+	if path := os.Getenv("SELECTORS_FILE"); path != "" {
+		if err := cfg.Scraper.LoadSelectorsFile(path); err != nil {
+			slog.Error("failed to load selectors", "path", path, "error", err)
+			os.Exit(1)
+		}
+	}
+	// PRICE_HISTORY=true appends every scraped price to the price_history table
+	cfg.Database.RecordPriceHistory = os.Getenv("PRICE_HISTORY") == "true"
+	// DB_ON_CONFLICT=ignore keeps stored listings instead of overwriting them
+	cfg.Database.OnConflict = os.Getenv("DB_ON_CONFLICT")
+	// DB_CONNECT_TIMEOUT is how long start-up waits for the database (e.g. "2m")
+	if v := os.Getenv("DB_CONNECT_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			slog.Error("invalid DB_CONNECT_TIMEOUT", "value", v, "error", err)
+			os.Exit(1)
+		}
+		cfg.Database.ConnectTimeout = d
+	}
+	// COOKIE_JAR persists browser cookies between runs (e.g. "cookies.json")
+	cfg.Browser.CookieJarPath = os.Getenv("COOKIE_JAR")
+
+	logger := utils.NewLogger(&cfg.Log)
+	slog.SetDefault(logger)
+
+	if cfg.Concurrency.ProductWorkers < 1 {
+		logger.Error("product workers must be at least 1", "workers", cfg.Concurrency.ProductWorkers)
+		os.Exit(1)
+	}
+	if cfg.Concurrency.ProductWorkers > config.MaxSafeProductWorkers {
+		logger.Warn("many product workers; each is a Chrome tab and may exhaust memory",
+			"workers", cfg.Concurrency.ProductWorkers, "recommended_max", config.MaxSafeProductWorkers)
+	}
+
 	// initialize app
-	app := application.NewApp(cfg)
+	app := application.NewApp(cfg, logger)
+
+	if *validateURL != "" {
+		if err := app.ValidateSelectors(ctx, *validateURL); err != nil {
+			logger.Error("selector validation failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *searchURL != "" {
+		if err := app.RunSearch(ctx, *searchURL); err != nil {
+			logger.Error("application failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *urlsFile != "" {
+		urls, err := readURLsFile(*urlsFile)
+		if err != nil {
+			logger.Error("failed to read urls file", "path", *urlsFile, "error", err)
+			os.Exit(1)
+		}
+		if err := app.RunURLs(ctx, urls); err != nil {
+			logger.Error("application failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// get URL from environment or use default
 	url := os.Getenv("SCRAPER_URL")
 	if url == "" {
-		log.Fatal("SCRAPER_URL environment variable not set")
+		logger.Error("SCRAPER_URL environment variable not set")
+		os.Exit(1)
+	}
+
+	if *collectOnly {
+		if err := app.CollectURLs(ctx, url); err != nil {
+			logger.Error("url collection failed", "error", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	// run the scraper
 	if err := app.Run(ctx, url); err != nil {
-		log.Fatalf("application failed: %v", err)
+		logger.Error("application failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// parseFloat32 returns a flag.Func setter that parses a number into dst.
+func parseFloat32(dst *float32) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 32)
+		if err != nil {
+			return err
+		}
+		*dst = float32(f)
+		return nil
+	}
+}
+
+// readURLsFile reads one URL per line, ignoring blank lines and # comments.
+// Lines holding a JSON object contribute its "url", so a -failures-file from
+// an earlier run can be read back as is. A path of "-" reads stdin, so URLs
+// can be piped in.
+func readURLsFile(path string) ([]string, error) {
+	if path == "-" {
+		return readURLs(os.Stdin, "stdin")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
-}
\ No newline at end of file
+	defer f.Close()
+	return readURLs(f, path)
+}
+
+// readURLs reads the URL list format of readURLsFile from r; name labels errors.
+func readURLs(r io.Reader, name string) ([]string, error) {
+	var urls []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			var record struct {
+				URL string `json:"url"`
+			}
+			if err := json.Unmarshal([]byte(line), &record); err != nil || record.URL == "" {
+				return nil, fmt.Errorf("%s: line %q is not a url or a json object with a url", name, line)
+			}
+			line = record.URL
+		}
+		urls = append(urls, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no urls in %s", name)
+	}
+	return urls, nil
+}
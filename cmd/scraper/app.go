@@ -4,56 +4,374 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"scraping-airbnb/config"
 	"scraping-airbnb/internal/domain"
+	"scraping-airbnb/internal/telemetry"
+	"scraping-airbnb/models"
+	"scraping-airbnb/scraper"
 	"scraping-airbnb/scraper/airbnb"
 	"scraping-airbnb/service"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func NewApp(cfg *config.Config) *App {
-	return &App{cfg: cfg}
+func NewApp(cfg *config.Config, logger *slog.Logger) *App {
+	return &App{cfg: cfg, log: logger}
 }
 
 type App struct {
 	cfg *config.Config
+	log *slog.Logger
 }
 
+// Run crawls from url, discovering listings through location and search pages.
 func (a *App) Run(ctx context.Context, url string) error {
-	log.Printf("scraper config: max_retries=%d, initial_backoff=%v, max_backoff=%v",
-		a.cfg.Retry.MaxRetries, a.cfg.Retry.InitialBackoff, a.cfg.Retry.MaxBackoff)
+	return a.run(ctx, func(ctx context.Context, svc *service.ScraperService) ([]models.Property, error) {
+		return svc.Run(ctx, url)
+	})
+}
+
+// RunURLs scrapes the given listing URLs directly.
+func (a *App) RunURLs(ctx context.Context, urls []string) error {
+	return a.run(ctx, func(ctx context.Context, svc *service.ScraperService) ([]models.Property, error) {
+		return svc.RunURLs(ctx, urls)
+	})
+}
+
+// run wires up the scraper and repository and calls scrape with a context
+// bounded by TotalRunTimeout, if set. Chrome shares that deadline, so the run
+// stops promptly when it expires; the service still saves what was scraped.
+func (a *App) run(ctx context.Context, scrape func(context.Context, *service.ScraperService) ([]models.Property, error)) error {
+	a.log.Info("scraper config",
+		"max_retries", a.cfg.Retry.MaxRetries,
+		"initial_backoff", a.cfg.Retry.InitialBackoff,
+		"max_backoff", a.cfg.Retry.MaxBackoff)
+
+	if a.cfg.Metrics.Enabled {
+		metricsCtx, stopMetrics := context.WithCancel(ctx)
+		defer stopMetrics()
+		go func() {
+			if err := telemetry.Serve(metricsCtx, a.cfg.Metrics.Addr, a.log); err != nil {
+				a.log.Error("metrics server failed", "error", err)
+			}
+		}()
+	}
 
-	chromedpScraper := airbnb.NewChromedpScraper(ctx)
+	runCtx := ctx
+	if limit := a.cfg.Timing.TotalRunTimeout; limit > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, limit)
+		defer cancel()
+		a.log.Info("run timeout set", "timeout", limit)
+	}
+
+	chromedpScraper := airbnb.NewChromedpScraper(runCtx, airbnb.Site{}, a.cfg, a.log)
+	defer chromedpScraper.Close()
+	if a.cfg.Output.Progress {
+		chromedpScraper.SetProgressReporter(scraper.NewStdoutProgress(nil))
+	}
+
+	repo, err := a.newRepository(ctx, a.cfg.Output.Format)
+	if err != nil {
+		return err
+	}
+
+	scraperService := service.NewScraperService(chromedpScraper, repo, a.cfg, a.log)
+	properties, err := scrape(runCtx, scraperService)
+	chromedpScraper.Metrics().Print(os.Stdout)
+	if saveErr := chromedpScraper.SaveCookies(); saveErr != nil {
+		a.log.Warn("failed to save cookies", "error", saveErr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("scraping failed: %w", err)
+	}
+
+	fmt.Printf("✓ Scraping completed successfully: %d properties saved\n", len(properties))
+
+	fmt.Println(properties)
+	return nil
+}
+
+// RunSearch scrapes a single search results page (or listing) directly.
+func (a *App) RunSearch(ctx context.Context, searchURL string) error {
+	return a.run(ctx, func(ctx context.Context, svc *service.ScraperService) ([]models.Property, error) {
+		return svc.RunSearch(ctx, searchURL)
+	})
+}
+
+// CollectURLs runs listing discovery from url without extracting any
+// listing, and saves the deduplicated listing URLs to the configured output,
+// which must be able to store a URL list (csv, json or stdout).
+func (a *App) CollectURLs(ctx context.Context, url string) error {
+	repo, err := a.newRepository(ctx, a.cfg.Output.Format)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	w, ok := repo.(domain.URLListWriter)
+	if !ok {
+		return fmt.Errorf("output %q cannot store a url list; use csv, json or stdout", a.cfg.Output.Format)
+	}
+
+	runCtx := ctx
+	if limit := a.cfg.Timing.TotalRunTimeout; limit > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, limit)
+		defer cancel()
+	}
+
+	chromedpScraper := airbnb.NewChromedpScraper(runCtx, airbnb.Site{}, a.cfg, a.log)
+	defer chromedpScraper.Close()
+
+	urls, err := chromedpScraper.CollectURLs(runCtx, url)
+	if se, ok := domain.AsScrapeError(err); ok {
+		// keep what the other location pages found
+		a.log.Warn("some location pages failed", "error", se)
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("collecting urls failed: %w", err)
+	}
+
+	if err := w.SaveURLs(ctx, urls); err != nil {
+		return fmt.Errorf("save urls: %w", err)
+	}
+	a.log.Info("listing urls saved", "count", len(urls), "output", a.cfg.Output.Format)
+	return nil
+}
+
+// ValidateSelectors runs every product-page extractor against url and prints
+// which ones matched. It saves nothing and fails if any required extractor
+// came back empty.
+func (a *App) ValidateSelectors(ctx context.Context, url string) error {
+	chromedpScraper := airbnb.NewChromedpScraper(ctx, airbnb.Site{}, a.cfg, a.log)
+	defer chromedpScraper.Close()
+
+	results, err := chromedpScraper.ValidateSelectors(ctx, url)
+	if err != nil {
+		return err
+	}
 
+	fmt.Printf("Selector check for %s\n", url)
+	airbnb.PrintSelectorReport(os.Stdout, results)
+
+	var empty int
+	for _, r := range results {
+		if !r.Matched() && !r.Optional {
+			empty++
+		}
+	}
+	if empty > 0 {
+		return fmt.Errorf("%d of %d selectors returned empty", empty, len(results))
+	}
+	return nil
+}
+
+// newRepository builds the repository selected by Output.Format (OUTPUT_FORMAT)
+// ("postgres" by default, "mongo", "elastic", "webhook", "csv", "json", or
+// "stdout"/"none" for dry runs).
+// A comma-separated list such as "postgres,csv" saves to all of them, with
+// the first as the primary store. The scraper service closes the repository
+// when the run ends.
+func (a *App) newRepository(ctx context.Context, format string) (domain.PropertyRepository, error) {
+	formats := strings.Split(format, ",")
+	if len(formats) == 1 {
+		return a.newSingleRepository(ctx, strings.TrimSpace(format))
+	}
+
+	var repos []domain.PropertyRepository
+	for _, f := range formats {
+		repo, err := a.newSingleRepository(ctx, strings.TrimSpace(f))
+		if err != nil {
+			domain.NewMultiRepository(repos...).Close()
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+
+	multi := domain.NewMultiRepository(repos...)
+	// OUTPUT_FAIL_FAST=true stops at the first repository that fails to save
+	multi.FailFast = os.Getenv("OUTPUT_FAIL_FAST") == "true"
+	return multi, nil
+}
+
+// newElasticRepository indexes into ELASTIC_INDEX (default "properties") on
+// the comma-separated ELASTIC_ADDRESSES (default http://localhost:9200).
+func (a *App) newElasticRepository(ctx context.Context) (domain.PropertyRepository, error) {
+	addresses := []string{"http://localhost:9200"}
+	if v := os.Getenv("ELASTIC_ADDRESSES"); v != "" {
+		addresses = strings.Split(v, ",")
+	}
+	index := os.Getenv("ELASTIC_INDEX")
+	if index == "" {
+		index = "properties"
+	}
+
+	repo, err := domain.NewElasticRepository(ctx, addresses, index)
+	if err != nil {
+		return nil, err
+	}
+
+	a.log.Info("elasticsearch connection successful", "index", index)
+	return repo, nil
+}
+
+// newSingleRepository builds the repository for one OUTPUT_FORMAT value.
+func (a *App) newSingleRepository(ctx context.Context, format string) (domain.PropertyRepository, error) {
+	switch format {
+	case "", "postgres":
+		return a.newPostgresRepository(ctx)
+	case "mongo":
+		return a.newMongoRepository(ctx)
+	case "elastic":
+		return a.newElasticRepository(ctx)
+	case "webhook":
+		url := os.Getenv("WEBHOOK_URL")
+		if url == "" {
+			return nil, fmt.Errorf("WEBHOOK_URL not set")
+		}
+		repo := domain.NewWebhookRepository(url, os.Getenv("WEBHOOK_TOKEN"))
+		if v := os.Getenv("WEBHOOK_TIMEOUT"); v != "" {
+			timeout, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid WEBHOOK_TIMEOUT: %w", err)
+			}
+			repo.Timeout = timeout
+		}
+		return repo, nil
+	case "csv":
+		path := os.Getenv("CSV_PATH")
+		if path == "" {
+			path = "properties.csv"
+		}
+		repo := domain.NewCSVRepository(path)
+		repo.Append = os.Getenv("CSV_APPEND") == "true"
+		repo.Stream = os.Getenv("CSV_STREAM") == "true"
+		repo.MaxDescriptionLength = a.cfg.Scraper.MaxDescriptionLength
+		return repo, nil
+	case "json":
+		path := os.Getenv("JSON_PATH")
+		if path == "" {
+			path = "properties.json"
+		}
+		repo := domain.NewJSONArrayRepository(path)
+		if v := os.Getenv("JSON_INDENT"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid JSON_INDENT %q: want a number of spaces", v)
+			}
+			repo.Indent = strings.Repeat(" ", n)
+		}
+		return repo, nil
+	case "stdout":
+		return domain.NewStdoutRepository(), nil
+	case "none":
+		return domain.NewNoOpRepository(), nil
+	default:
+		return nil, fmt.Errorf("unknown OUTPUT_FORMAT %q", format)
+	}
+}
+
+func (a *App) newPostgresRepository(ctx context.Context) (domain.PropertyRepository, error) {
 	// connect to postgres (defaults match docker-compose)
 	dsn := os.Getenv("PG_DSN")
 	if dsn == "" {
-		return fmt.Errorf("db connection string not found")
+		return nil, fmt.Errorf("db connection string not found")
 	}
 
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		return fmt.Errorf("failed to create db connection: %w", err)
+		return nil, fmt.Errorf("failed to create db connection: %w", err)
 	}
-	defer db.Close()
 
-	if err := db.PingContext(ctx); err != nil {
-		return fmt.Errorf("failed to ping db: %w", err)
+	if err := a.pingDB(ctx, db, a.cfg.Database.ConnectTimeout); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping db: %w", err)
 	}
 
-	log.Println("db connection successful")
+	a.log.Info("db connection successful")
 
 	repo := domain.NewPostgresRepository(db)
-	scraperService := service.NewScraperService(chromedpScraper, repo, a.cfg)
-	properties, err := scraperService.Run(ctx, url)
+	if err := repo.Migrate(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate db schema: %w", err)
+	}
+	switch mode := a.cfg.Database.OnConflict; mode {
+	case "":
+	case domain.ConflictUpsert, domain.ConflictIgnore:
+		repo.OnConflict = mode
+	default:
+		db.Close()
+		return nil, fmt.Errorf("unknown DB_ON_CONFLICT %q: want %q or %q", mode, domain.ConflictUpsert, domain.ConflictIgnore)
+	}
+	repo.BatchSize = a.cfg.Database.BatchSize
+	repo.RecordHistory = a.cfg.Database.RecordPriceHistory
+	repo.MaxRetries = a.cfg.Database.TxRetries
+	repo.RetryDelay = a.cfg.Database.TxRetryDelay
+	return repo, nil
+}
 
-	if err != nil {
-		return fmt.Errorf("scraping failed: %w", err)
+// pingDB pings db until it answers or timeout passes, so a database still
+// starting up (as under docker-compose) is waited for. Waits between attempts
+// start at Retry.InitialBackoff and double up to Retry.MaxBackoff.
+func (a *App) pingDB(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return db.PingContext(ctx)
 	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	fmt.Printf("✓ Scraping completed successfully: %d properties saved\n", len(properties))
+	backoff := a.cfg.Retry.InitialBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("gave up after %d attempts in %v: %w", attempt, timeout, err)
+		}
+		a.log.Warn("db not reachable yet; retrying", "attempt", attempt, "backoff", backoff, "error", err)
 
-	fmt.Println(properties)
-	return nil
-}
\ No newline at end of file
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after %d attempts in %v: %w", attempt, timeout, err)
+		}
+		if a.cfg.Retry.MaxBackoff > 0 {
+			backoff = min(backoff*2, a.cfg.Retry.MaxBackoff)
+		} else {
+			backoff *= 2
+		}
+	}
+}
+
+func (a *App) newMongoRepository(ctx context.Context) (domain.PropertyRepository, error) {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		return nil, fmt.Errorf("mongo connection string not found")
+	}
+
+	dbName := os.Getenv("MONGO_DB")
+	if dbName == "" {
+		dbName = "scraping"
+	}
+	collection := os.Getenv("MONGO_COLLECTION")
+	if collection == "" {
+		collection = "properties"
+	}
+
+	repo, err := domain.NewMongoRepository(ctx, uri, dbName, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	a.log.Info("mongo connection successful")
+	return repo, nil
+}
@@ -1,6 +1,12 @@
 package config
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
 
 // BrowserConfig controls headless Chrome flags.
 type BrowserConfig struct {
@@ -9,6 +15,21 @@ type BrowserConfig struct {
 	NoSandbox  bool
 	DisableShm bool
 	UserAgent  string
+	// Browser window size in pixels; Airbnb switches to a mobile layout on narrow windows
+	WindowWidth  int
+	WindowHeight int
+	// File used to persist cookies between runs ("" = start every run cold)
+	CookieJarPath string
+	// Product pages a pooled tab serves before it is closed and reopened,
+	// bounding Chrome memory growth (<= 1 = a fresh tab for every page)
+	TabMaxUses int
+	// Product pages after which Chrome is shut down and relaunched to reclaim
+	// the memory it accumulates on long runs (0 = never)
+	RestartEvery int
+	// Resource types aborted before they load, to cut page load time and
+	// bandwidth: image, media, font, stylesheet, script, ping or other.
+	// Leave out "image" when image URLs are extracted from the page.
+	BlockResources []string
 }
 
 // TimingConfig controls all wait/sleep durations throughout the scraper.
@@ -25,6 +46,24 @@ type TimingConfig struct {
 	ProductPageWait time.Duration
 	// Hard timeout for a single product page extraction
 	ProductTimeout time.Duration
+	// Hard timeout for the homepage location-links crawl, retries included
+	LocationPageTimeout time.Duration
+	// Hard timeout for one search results page of a location, retries included
+	// (0 = unbounded)
+	CardPageTimeout time.Duration
+	// Wait before re-extracting a required field that came back empty
+	FieldRetryDelay time.Duration
+	// Max wait for the price and rating sections to render; absent sections are skipped after this
+	SectionWaitTimeout time.Duration
+	// Max wait for the title, booking and location sections, so one that never
+	// renders doesn't use up the whole ProductTimeout
+	ElementWaitTimeout time.Duration
+	// PageWaitFixed sleeps PageLoadWait/ProductPageWait after navigating;
+	// PageWaitNetworkIdle waits until the network goes quiet, up to NetworkIdleTimeout
+	PageWaitMode       string
+	NetworkIdleTimeout time.Duration
+	// Hard cap on a whole run; listings scraped by then are still saved (0 = unlimited)
+	TotalRunTimeout time.Duration
 }
 
 // ConcurrencyConfig controls goroutine and worker pool limits.
@@ -35,6 +74,10 @@ type ConcurrencyConfig struct {
 	ProductWorkers int
 }
 
+// MaxSafeProductWorkers is the product worker count above which a warning is
+// logged: each worker holds a Chrome tab, so memory use grows with it.
+const MaxSafeProductWorkers = 20
+
 // ScraperConfig controls extraction limits.
 type ScraperConfig struct {
 	// Cards to collect from page 1 of a location search
@@ -43,8 +86,175 @@ type ScraperConfig struct {
 	CardsPage2 int
 	// Pixels to advance per scroll step
 	ScrollStep int
+	// ScrollModeFixed scrolls to the initial page height; ScrollModeDynamic keeps
+	// scrolling until the height stops growing
+	ScrollMode string
+	// Safety cap on scroll steps in dynamic mode
+	MaxScrollIterations int
+	// Max listings to extract per run after deduplication (0 = unlimited)
+	MaxProperties int
+	// Max distinct listings collected from one location, across its result
+	// pages, so big cities don't dominate the sample (0 = unlimited)
+	MaxPerLocation int
+	// Nightly price range to keep before saving (0 = no bound)
+	MinPrice float32
+	MaxPrice float32
+	// Keep listings whose price failed to parse (0)
+	IncludeUnpriced bool
+	// Minimum rating to keep before saving (0 = no minimum)
+	MinRating float32
+	// Keep listings that have no rating yet (0)
+	KeepUnrated bool
+	// Locale and currency forced on every page so text and prices are consistent
+	// ("" = let the site infer them)
+	Locale   string
+	Currency string
+	// Re-scrape listings that are already stored instead of skipping them
+	Refresh bool
+	// Also re-scrape stored listings last scraped longer ago than this (0 = off)
+	RefreshAfter time.Duration
+	// Fail the run when more than this share of URLs fail (0 = never, e.g. 0.5 = half)
+	MaxFailureRatio float64
+	// CSS selectors per product-page field (see Selector* keys), tried in order
+	Selectors map[string][]string
+	// Fields (Selector* keys) that must not be empty; a listing missing one is
+	// re-extracted up to FieldRetries times and then counted as failed
+	RequiredFields []string
+	FieldRetries   int
+	// Truncate descriptions in CSV output to this many characters (0 = full text);
+	// databases always store the full text
+	MaxDescriptionLength int
+	// Search pages crawled instead of the homepage's location links when
+	// discovery finds none, e.g. "https://www.airbnb.com/s/Paris/homes"
+	SeedSearchURLs []string
+	// Stop paginating a search once a whole results page holds only stored
+	// listings; for incremental crawls of newest-first searches
+	StopAtKnownPage bool
+	// Review texts collected per listing from the reviews modal (0 = none)
+	MaxReviews int
+	// Stays each listing is also priced for, e.g. upcoming weekends to see
+	// seasonality (empty = only the default price)
+	DateRanges []DateRange
+}
+
+// DateRange is a stay, from check-in to check-out day.
+type DateRange struct {
+	CheckIn  time.Time
+	CheckOut time.Time
+}
+
+// Nights returns the length of the stay.
+func (r DateRange) Nights() int {
+	return int(r.CheckOut.Sub(r.CheckIn).Hours() / 24)
+}
+
+// ParseDateRanges parses comma-separated check-in:check-out pairs such as
+// "2025-07-04:2025-07-06,2025-07-11:2025-07-13".
+func ParseDateRanges(s string) ([]DateRange, error) {
+	var ranges []DateRange
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		in, out, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("date range %q: want check-in:check-out", pair)
+		}
+		checkIn, err := time.Parse(time.DateOnly, in)
+		if err != nil {
+			return nil, fmt.Errorf("date range %q: %w", pair, err)
+		}
+		checkOut, err := time.Parse(time.DateOnly, out)
+		if err != nil {
+			return nil, fmt.Errorf("date range %q: %w", pair, err)
+		}
+		if !checkOut.After(checkIn) {
+			return nil, fmt.Errorf("date range %q: check-out must be after check-in", pair)
+		}
+		ranges = append(ranges, DateRange{CheckIn: checkIn, CheckOut: checkOut})
+	}
+	return ranges, nil
 }
 
+// Keys of ScraperConfig.Selectors.
+const (
+	SelectorTitle    = "title"
+	SelectorPrice    = "price"
+	SelectorNights   = "nights"
+	SelectorRating   = "rating"
+	SelectorLocation = "location"
+	// Homepage anchors leading to location search pages
+	SelectorLocationLinks = "location_links"
+)
+
+// DefaultSelectors returns the built-in product-page and homepage selectors,
+// most specific/stable first.
+func DefaultSelectors() map[string][]string {
+	return map[string][]string{
+		SelectorTitle:  {".tglziin > h1"},
+		SelectorPrice:  {".u1opajno", ".u174bpcy", ".uhx2ipv"},
+		SelectorNights: {".q5ltwoj", ".q1tsro90 > span", ".qesosmo"},
+		SelectorRating: {
+			`[data-testid="pdp-reviews-highlight-banner-host-rating"] div[aria-hidden="true"]`,
+			".rmtgcc3",
+			".r1lcxetl",
+		},
+		SelectorLocation: {"._1t2xqmi > h3", ".s1qk96pm"},
+		// class names first; the scraper falls back to heading links whose
+		// href looks like a search page, which survives redesigns
+		SelectorLocationLinks: {".c1ol07tf a", "h2.skp76t2 > a"},
+	}
+}
+
+// LoadSelectorsFile overrides Selectors with the JSON object in path, e.g.
+// {"price": [".new-price", ".u1opajno"]}. Fields absent from the file keep
+// their current selectors.
+func (c *ScraperConfig) LoadSelectorsFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read selectors: %w", err)
+	}
+
+	var overrides map[string][]string
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return fmt.Errorf("parse selectors %s: %w", path, err)
+	}
+
+	if c.Selectors == nil {
+		c.Selectors = DefaultSelectors()
+	}
+	for field, selectors := range overrides {
+		c.Selectors[field] = selectors
+	}
+	return nil
+}
+
+// Delay distributions for StealthConfig.DelayDistribution.
+const (
+	DelayUniform = "uniform"
+	DelayNormal  = "normal"
+)
+
+// Device profiles for StealthConfig.Device.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceMixed   = "mixed"
+)
+
+// Scroll modes for ScraperConfig.ScrollMode.
+const (
+	ScrollModeFixed   = "fixed"
+	ScrollModeDynamic = "dynamic"
+)
+
+// Page wait modes for TimingConfig.PageWaitMode.
+const (
+	PageWaitFixed       = "fixed"
+	PageWaitNetworkIdle = "networkidle"
+)
+
 // RetryConfig controls retry behavior for resilience.
 type RetryConfig struct {
 	// Max number of retry attempts for failed operations
@@ -53,6 +263,20 @@ type RetryConfig struct {
 	InitialBackoff time.Duration
 	// Max backoff duration (caps exponential growth)
 	MaxBackoff time.Duration
+	// Retry pages that returned a captcha instead of giving up. Off by default:
+	// a bot check rarely clears within one run, so the page fails at once
+	// rather than spending its retries. The fresh user agent and
+	// CaptchaBackoff below only apply when this is set.
+	RetryOnCaptcha bool
+	// Backoff used instead of the exponential value when retrying a captcha
+	// (only with RetryOnCaptcha)
+	CaptchaBackoff time.Duration
+	// Randomize each backoff in [0, backoff] so concurrent retries don't line up
+	JitterEnabled bool
+	// Seed for the jitter RNG (0 = seed from the clock)
+	JitterSeed int64
+	// Total retries allowed across all operations in one run (0 = unlimited)
+	GlobalBudget int64
 }
 
 // StealthConfig controls anti-detection and stealth behavior.
@@ -63,10 +287,105 @@ type StealthConfig struct {
 	RandomDelayMin time.Duration
 	// Max random delay (ms)
 	RandomDelayMax time.Duration
+	// How delays spread over [RandomDelayMin, RandomDelayMax]: DelayUniform
+	// (default), or DelayNormal clustering around the midpoint
+	DelayDistribution string
 	// Enable random user agent selection
 	RandomUserAgentEnabled bool
-	// Max requests per second (rate limiting; 0 = unlimited)
+	// Which user agents tabs present: DeviceDesktop (default), DeviceMobile
+	// with a phone-sized touch viewport, or DeviceMixed picking per tab.
+	// Mobile pages use other markup, so pair mobile with a SELECTORS_FILE
+	Device string
+	// Max requests per second (rate limiting; 0 = unlimited), applied per host
 	MaxRequestsPerSecond int64
+	// Per-host overrides of MaxRequestsPerSecond, keyed by hostname (e.g. "www.airbnb.com")
+	HostRequestsPerSecond map[string]int64
+	// Skip URLs disallowed by the host's robots.txt (disable only with permission)
+	RespectRobots bool
+	// Slow the rate limiter down when captchas/failed pages appear, and speed
+	// back up on success (opt-in; off keeps the configured rate fixed)
+	AdaptiveRateLimit bool
+	// Slowest interval the adaptive rate limiter backs off to
+	MaxRateLimitInterval time.Duration
+	// Seed for random delays and user agent choice (0 = seed from the clock);
+	// fix it to make a run's stealth behavior reproducible
+	Seed int64
+	// HTTP headers sent with every request of every tab, set before its first
+	// navigation; a Locale for another language overrides Accept-Language
+	ExtraHeaders map[string]string
+	// Hide headless-Chrome tells (navigator.webdriver, empty plugins, ...)
+	// from page scripts in every tab
+	FingerprintMasking bool
+}
+
+// DatabaseConfig controls how results are persisted.
+type DatabaseConfig struct {
+	// Rows committed per transaction when saving
+	BatchSize int
+	// Append every scraped price to price_history (Postgres only)
+	RecordPriceHistory bool
+	// How a listing already stored is handled (Postgres only): "upsert"
+	// overwrites it, "ignore" keeps the stored row (empty = upsert)
+	OnConflict string
+	// Retries of a batch aborted by a serialization failure or deadlock with
+	// another writer (Postgres only)
+	TxRetries int
+	// Wait before the first such retry; doubles on each one
+	TxRetryDelay time.Duration
+	// How long start-up keeps retrying a database that isn't reachable yet,
+	// backing off as RetryConfig does (0 = a single attempt)
+	ConnectTimeout time.Duration
+}
+
+// OutputConfig controls reports written alongside the scraped data.
+type OutputConfig struct {
+	// Where scraped listings are saved, as accepted by OUTPUT_FORMAT (empty = postgres)
+	Format string
+	// Path to write the insights report as JSON (empty = console only)
+	InsightsJSONPath string
+	// Print a "scraped n/total" line as each listing is extracted
+	Progress bool
+	// Path to write the listings that failed as JSONL (url, error, attempts),
+	// readable again by -urls-file (empty = don't write)
+	FailuresPath string
+	// Save listings in batches of this many while the crawl is still running,
+	// so a crash keeps what was saved (0 = save everything at the end)
+	SaveEvery int
+}
+
+// DebugConfig controls diagnostic output used when fixing selectors.
+type DebugConfig struct {
+	// Dump the page HTML when a product or search page fails to extract
+	DumpHTMLOnFailure bool
+	// Dump the page HTML for every product page, even on success
+	DumpHTMLAlways bool
+	// Directory debug files are written to
+	Dir string
+	// Log the number of open browser tabs this often, to spot leaked tabs
+	// during long runs (0 = off)
+	TargetCountInterval time.Duration
+}
+
+// LogConfig controls structured log output.
+type LogConfig struct {
+	// LogFormatText or LogFormatJSON
+	Format string
+	// Minimum level: "debug", "info", "warn" or "error"
+	Level string
+}
+
+// Log formats for LogConfig.Format.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// MetricsConfig controls the optional Prometheus metrics endpoint.
+type MetricsConfig struct {
+	// Serve /metrics while the scraper runs
+	Enabled bool
+	// Listen address for the metrics server
+	Addr string
 }
 
 // Config is the root configuration passed into the scraper.
@@ -77,6 +396,11 @@ type Config struct {
 	Scraper     ScraperConfig
 	Retry       RetryConfig
 	Stealth     StealthConfig
+	Database    DatabaseConfig
+	Output      OutputConfig
+	Debug       DebugConfig
+	Log         LogConfig
+	Metrics     MetricsConfig
 }
 
 // Default returns a conservative production-ready configuration.
@@ -88,35 +412,84 @@ func Default() *Config {
 			NoSandbox:  true,
 			DisableShm: true,
 			UserAgent:  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			// desktop layout, which the default selectors target
+			WindowWidth:  1920,
+			WindowHeight: 1080,
+			TabMaxUses:   50,
+			RestartEvery: 500,
 		},
 		Timing: TimingConfig{
-			PageLoadWait:     5 * time.Second,
-			ScrollStepDelay:  400 * time.Millisecond,
-			ScrollBottomWait: 4 * time.Second,
-			AfterScrollWait:  4 * time.Second,
-			ProductPageWait:  4 * time.Second,
-			ProductTimeout:   70 * time.Second,
+			PageLoadWait:        5 * time.Second,
+			ScrollStepDelay:     400 * time.Millisecond,
+			ScrollBottomWait:    4 * time.Second,
+			AfterScrollWait:     4 * time.Second,
+			ProductPageWait:     4 * time.Second,
+			ProductTimeout:      70 * time.Second,
+			LocationPageTimeout: 3 * time.Minute,
+			CardPageTimeout:     3 * time.Minute,
+			FieldRetryDelay:     time.Second,
+			SectionWaitTimeout:  5 * time.Second,
+			ElementWaitTimeout:  8 * time.Second,
+			PageWaitMode:        PageWaitNetworkIdle,
+			NetworkIdleTimeout:  10 * time.Second,
 		},
 		Concurrency: ConcurrencyConfig{
 			LocationWorkers: 3,
 			ProductWorkers:  3,
 		},
 		Scraper: ScraperConfig{
-			CardsPage1: 5,
-			CardsPage2: 5,
-			ScrollStep: 400,
+			CardsPage1:          5,
+			CardsPage2:          5,
+			ScrollStep:          400,
+			ScrollMode:          ScrollModeDynamic,
+			MaxScrollIterations: 200,
+			IncludeUnpriced:     true,
+			KeepUnrated:         true,
+			Locale:              "en",
+			Currency:            "USD",
+			Selectors:           DefaultSelectors(),
+			RequiredFields:      []string{SelectorTitle, SelectorPrice},
+			FieldRetries:        2,
 		},
 		Retry: RetryConfig{
 			MaxRetries:     3,
 			InitialBackoff: 2 * time.Second,
 			MaxBackoff:     10 * time.Second,
+			CaptchaBackoff: 30 * time.Second,
+			JitterEnabled:  true,
 		},
 		Stealth: StealthConfig{
 			RandomDelayEnabled:     true,
 			RandomDelayMin:         4 * time.Second,
 			RandomDelayMax:         6 * time.Second,
+			DelayDistribution:      DelayUniform,
 			RandomUserAgentEnabled: true,
+			Device:                 DeviceDesktop,
 			MaxRequestsPerSecond:   4,
+			RespectRobots:          true,
+			MaxRateLimitInterval:   30 * time.Second,
+			ExtraHeaders: map[string]string{
+				"Accept-Language": "en-US,en;q=0.9",
+			},
+		},
+		Database: DatabaseConfig{
+			BatchSize:      500,
+			TxRetries:      3,
+			TxRetryDelay:   100 * time.Millisecond,
+			ConnectTimeout: time.Minute,
+		},
+		Output: OutputConfig{
+			SaveEvery: 10,
+		},
+		Debug: DebugConfig{
+			Dir: "debug",
+		},
+		Log: LogConfig{
+			Format: LogFormatText,
+			Level:  "info",
+		},
+		Metrics: MetricsConfig{
+			Addr: ":2112",
 		},
 	}
 }
@@ -136,7 +509,6 @@ func Dev() *Config {
 	return cfg
 }
 
-
 // DefaultUserAgents returns a pool of realistic desktop browser user agents.
 func DefaultUserAgents() []string {
 	return []string{
@@ -150,3 +522,14 @@ func DefaultUserAgents() []string {
 		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
 	}
 }
+
+// DefaultMobileUserAgents returns a pool of realistic phone browser user agents.
+func DefaultMobileUserAgents() []string {
+	return []string{
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+		"Mozilla/5.0 (Linux; Android 13; SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+		"Mozilla/5.0 (Linux; Android 14; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.6045.193 Mobile Safari/537.36",
+	}
+}
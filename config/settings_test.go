@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateRanges(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+
+	tests := []struct {
+		name    string
+		in      string
+		want    []DateRange
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"one", "2025-07-04:2025-07-06", []DateRange{{day("2025-07-04"), day("2025-07-06")}}, false},
+		{"several with spaces", " 2025-07-04:2025-07-06 , 2025-12-24:2025-12-27,", []DateRange{
+			{day("2025-07-04"), day("2025-07-06")},
+			{day("2025-12-24"), day("2025-12-27")},
+		}, false},
+		{"reversed", "2025-07-06:2025-07-04", nil, true},
+		{"same day", "2025-07-04:2025-07-04", nil, true},
+		{"missing check-out", "2025-07-04", nil, true},
+		{"bad check-in", "07/04/2025:2025-07-06", nil, true},
+		{"bad check-out", "2025-07-04:2025-13-01", nil, true},
+		{"one bad among good", "2025-07-04:2025-07-06,2025-07-10", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateRanges(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDateRanges(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseDateRanges(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+			for i := range got {
+				if !got[i].CheckIn.Equal(tt.want[i].CheckIn) || !got[i].CheckOut.Equal(tt.want[i].CheckOut) {
+					t.Errorf("range %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
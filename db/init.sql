@@ -1,12 +1,72 @@
 CREATE TABLE IF NOT EXISTS properties (
     id SERIAL PRIMARY KEY,
+    room_id TEXT,
     platform TEXT NOT NULL,
     title TEXT,
     price REAL,
+    price_type TEXT,
+    nights INTEGER,
     location TEXT,
-    url TEXT UNIQUE,
+    url TEXT,
     rating REAL,
-    description TEXT
+    description TEXT,
+    property_type TEXT,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    scraped_at TIMESTAMPTZ,
+    reviews JSONB
 );
 
+-- databases created before these columns existed; every column Save
+-- writes is listed, so Migrate brings any older table up to date
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS room_id TEXT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS platform TEXT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS title TEXT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS price REAL;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS price_type TEXT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS nights INTEGER;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS location TEXT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS url TEXT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS rating REAL;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS description TEXT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS property_type TEXT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS scraped_at TIMESTAMPTZ;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS reviews JSONB;
+
+-- ExistingURLs looks listings up by url; the unique key on room_id that
+-- Save upserts on is built by migration 1 (db/migrations), which first
+-- merges rooms stored more than once
+CREATE INDEX IF NOT EXISTS idx_properties_url ON properties (url);
 CREATE INDEX IF NOT EXISTS idx_properties_location ON properties (location);
+CREATE INDEX IF NOT EXISTS idx_properties_scraped_at ON properties (scraped_at);
+
+-- one row per listing per run; properties only keeps the latest price
+CREATE TABLE IF NOT EXISTS price_history (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    price REAL,
+    scraped_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_url_scraped_at ON price_history (url, scraped_at);
+
+-- nightly price per listing for each configured stay (ScraperConfig.DateRanges),
+-- one row per stay per run, to track seasonality
+CREATE TABLE IF NOT EXISTS date_prices (
+    id BIGSERIAL PRIMARY KEY,
+    room_id TEXT NOT NULL,
+    check_in DATE NOT NULL,
+    check_out DATE NOT NULL,
+    price REAL,
+    scraped_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_date_prices_room_id_check_in ON date_prices (room_id, check_in);
+
+-- one row per one-off data migration (db.Migrations) already applied
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
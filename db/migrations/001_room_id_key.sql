@@ -0,0 +1,20 @@
+-- Make room_id the key of properties. Runs once per database, recorded in
+-- schema_migrations.
+
+-- idx_properties_room_id below is the one unique key on room_id
+ALTER TABLE properties DROP CONSTRAINT IF EXISTS properties_room_id_key;
+-- the same room can be reached by several urls, so url is no longer unique
+ALTER TABLE properties DROP CONSTRAINT IF EXISTS properties_url_key;
+
+-- rows saved before room_id existed; upserts now conflict on room_id
+UPDATE properties SET room_id = substring(url FROM '/(?:rooms(?:/plus)?|luxury/listing)/(\d+)')
+    WHERE room_id IS NULL;
+-- the same room saved under several urls (query strings) before urls were
+-- canonical: keep the most recently scraped row so the unique index can build
+DELETE FROM properties WHERE id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (PARTITION BY room_id ORDER BY scraped_at DESC NULLS LAST, id DESC) AS n
+        FROM properties WHERE room_id IS NOT NULL
+    ) ranked WHERE n > 1
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_properties_room_id ON properties (room_id);
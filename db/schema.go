@@ -0,0 +1,30 @@
+// Package db holds the Postgres schema. docker-compose runs init.sql on a
+// fresh database and PostgresRepository.Migrate runs it on every start,
+// followed by any of Migrations the database has not had yet.
+package db
+
+import _ "embed"
+
+// Init is the contents of init.sql. Every statement in it is idempotent: it
+// only holds CREATE ... IF NOT EXISTS and ADD COLUMN IF NOT EXISTS, and
+// anything touching existing rows belongs in Migrations.
+//
+//go:embed init.sql
+var Init string
+
+//go:embed migrations/001_room_id_key.sql
+var roomIDKey string
+
+// Migration is a one-off change to existing data or constraints, which unlike
+// Init must not run again once applied.
+type Migration struct {
+	// Version orders migrations and is recorded in schema_migrations once applied.
+	Version int
+	SQL     string
+}
+
+// Migrations are applied in order after Init, each in the same transaction
+// as the schema_migrations row recording it.
+var Migrations = []Migration{
+	{Version: 1, SQL: roomIDKey},
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// idempotentStmt matches the only statements Init may hold: ones that create
+// or add what is missing and leave existing data alone.
+var idempotentStmt = regexp.MustCompile(`^(CREATE (UNIQUE )?(TABLE|INDEX) IF NOT EXISTS|ALTER TABLE \w+ ADD COLUMN IF NOT EXISTS) `)
+
+func TestInitOnlyCreatesWhatIsMissing(t *testing.T) {
+	var script strings.Builder
+	for _, line := range strings.Split(Init, "\n") {
+		line, _, _ = strings.Cut(line, "--")
+		script.WriteString(line + "\n")
+	}
+
+	for _, stmt := range strings.Split(script.String(), ";") {
+		stmt = strings.Join(strings.Fields(stmt), " ")
+		if stmt == "" {
+			continue
+		}
+		if !idempotentStmt.MatchString(stmt) {
+			t.Errorf("init.sql statement %q is not CREATE/ADD COLUMN IF NOT EXISTS; move it to a migration", stmt)
+		}
+	}
+}
+
+func TestMigrationVersionsIncrease(t *testing.T) {
+	last := 0
+	for _, m := range Migrations {
+		if m.Version <= last {
+			t.Errorf("migration version %d follows %d, want increasing versions", m.Version, last)
+		}
+		if strings.TrimSpace(m.SQL) == "" {
+			t.Errorf("migration %d is empty", m.Version)
+		}
+		last = m.Version
+	}
+}
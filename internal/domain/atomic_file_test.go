@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicWriteKeepsOldFileOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.csv")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("disk full")
+	err := atomicWrite(path, func(w io.Writer) error {
+		io.WriteString(w, "half a ro")
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("atomicWrite() error = %v, want %v", err, failed)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "old\n" {
+		t.Errorf("file = %q after failed write, want previous content", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
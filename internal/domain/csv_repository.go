@@ -3,54 +3,382 @@ package domain
 import (
 	"context"
 	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"scraping-airbnb/models"
+	"scraping-airbnb/utils"
 	"strconv"
+	"sync"
+	"time"
 )
 
 type CSVRepository struct {
 	filePath string
+	// Delimiter separates fields (',' by default; ';' suits European spreadsheet tools)
+	Delimiter rune
+	// UseCRLF ends rows with \r\n for Windows Excel compatibility
+	UseCRLF bool
+	// MaxDescriptionLength truncates descriptions with an ellipsis (0 = full text)
+	MaxDescriptionLength int
+	// Append adds rows to an existing file instead of truncating it; the header
+	// is only written when the file is new or empty
+	Append bool
+	// Stream makes Save append rows to one temp file kept open until Close
+	// (see OpenStream) instead of rewriting the whole file on every call
+	Stream bool
+
+	mu     sync.Mutex
+	stream *CSVStream
+	// saved is set once Save has written the file, so later batches of the
+	// same run are added to it rather than replacing it
+	saved bool
 }
 
 func NewCSVRepository(filePath string) *CSVRepository {
 	return &CSVRepository{
-		filePath: filePath,
+		filePath:  filePath,
+		Delimiter: ',',
 	}
 }
 
 func (r *CSVRepository) Save(ctx context.Context, products []models.Property) error {
 
-	file, err := os.Create(r.filePath)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Stream {
+		return r.saveStream(ctx, products)
+	}
+
+	// the new file is built beside the old one and renamed over it, so a
+	// crash mid-save leaves the previous file intact
+	err := atomicWrite(r.filePath, func(w io.Writer) error {
+		empty := true
+		// batches after the first keep the rows this run already saved
+		if r.Append || r.saved {
+			n, err := copyExisting(w, r.filePath)
+			if err != nil {
+				return err
+			}
+			empty = n == 0
+		}
+
+		var header []string
+		if empty {
+			header = propertyHeader
+		}
+		writer := r.newWriter(w, header)
+		for _, p := range products {
+			writer.Write(r.record(p))
+		}
+
+		// fields containing the delimiter, quotes or newlines are quoted by csv.Writer
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("write csv: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	r.saved = true
+	return nil
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+// saveStream appends products to the repository's stream, opening it on the
+// first call, and flushes them to disk. Callers must hold r.mu.
+func (r *CSVRepository) saveStream(ctx context.Context, products []models.Property) error {
+	if r.stream == nil {
+		stream, err := r.OpenStream()
+		if err != nil {
+			return fmt.Errorf("open csv stream: %w", err)
+		}
+		r.stream = stream
+	}
+	for _, p := range products {
+		if err := r.stream.AppendOne(ctx, p); err != nil {
+			return err
+		}
+	}
+	return r.stream.Flush()
+}
+
+// SaveURLs replaces the file with urls, one per line under a "URL" header.
+// Append and the formatting options apply as in Save.
+func (r *CSVRepository) SaveURLs(ctx context.Context, urls []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// header
-	writer.Write([]string{
-		"Title",
-		"Price",
-		"Location",
-		"URL",
-		"Rating",
-		"Description",
+	return atomicWrite(r.filePath, func(w io.Writer) error {
+		empty := true
+		if r.Append {
+			n, err := copyExisting(w, r.filePath)
+			if err != nil {
+				return err
+			}
+			empty = n == 0
+		}
+
+		var header []string
+		if empty {
+			header = []string{"URL"}
+		}
+		writer := r.newWriter(w, header)
+		for _, u := range urls {
+			writer.Write([]string{u})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("write csv: %w", err)
+		}
+		return nil
 	})
+}
 
-	for _, p := range products {
+// copyExisting copies the file at path, if there is one, into w and reports
+// how many bytes it held.
+func copyExisting(w io.Writer, path string) (int64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("open existing csv: %w", err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(w, f)
+	if err != nil {
+		return 0, fmt.Errorf("copy existing csv: %w", err)
+	}
+	return n, nil
+}
+
+// open creates a temp file beside the repository's file, copies the existing
+// rows into it if Append is set, and writes the header if it is still empty.
+// Streams write there and rename it over the file on Close, so a crashed run
+// leaves the previous file intact and its own rows in the temp file.
+func (r *CSVRepository) open() (*os.File, *csv.Writer, error) {
+	file, err := os.CreateTemp(filepath.Dir(r.filePath), "."+filepath.Base(r.filePath)+".*.tmp")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create temp file: %w", err)
+	}
+
+	var n int64
+	if r.Append {
+		if n, err = copyExisting(file, r.filePath); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return nil, nil, err
+		}
+	}
+
+	var header []string
+	if n == 0 {
+		header = propertyHeader
+	}
+	return file, r.newWriter(file, header), nil
+}
+
+// propertyHeader names the columns written by record. Columns added later go
+// at the end, so readers indexing the older ones keep working.
+var propertyHeader = []string{
+	"Title",
+	"Price",
+	"Location",
+	"URL",
+	"Rating",
+	"Description",
+	"PropertyType",
+	"Latitude",
+	"Longitude",
+	"ScrapedAt",
+	"RoomID",
+	"PriceType",
+	"Nights",
+}
+
+// newWriter returns a csv.Writer on w with the repository's formatting,
+// having written header if it is not nil.
+func (r *CSVRepository) newWriter(w io.Writer, header []string) *csv.Writer {
+	writer := csv.NewWriter(w)
+	if r.Delimiter != 0 {
+		writer.Comma = r.Delimiter
+	}
+	writer.UseCRLF = r.UseCRLF
+
+	if header != nil {
+		writer.Write(header)
+	}
+	return writer
+}
+
+// record formats p as one CSV row.
+func (r *CSVRepository) record(p models.Property) []string {
+	return []string{
+		p.Title,
+		strconv.FormatFloat(float64(p.Price), 'f', 2, 32),
+		p.Location,
+		p.URL,
+		strconv.FormatFloat(float64(p.Rating), 'f', 2, 32),
+		utils.Truncate(p.Description, r.MaxDescriptionLength),
+		string(p.PropertyType),
+		strconv.FormatFloat(p.Latitude, 'f', 6, 64),
+		strconv.FormatFloat(p.Longitude, 'f', 6, 64),
+		p.ScrapedAt.Format(time.RFC3339),
+		p.RoomID,
+		string(p.PriceType),
+		nights(p.Nights),
+	}
+}
 
-		writer.Write([]string{
-			p.Title,
-			strconv.FormatFloat(float64(p.Price), 'f', 2, 32),
-			p.Location,
-			p.URL,
-			strconv.FormatFloat(float64(p.Rating), 'f', 2, 32),
-			p.Description,
-			
-		})
+// nights formats a Property.Nights value, leaving it empty when the page
+// showed none.
+func nights(n int) string {
+	if n == 0 {
+		return ""
 	}
+	return strconv.Itoa(n)
+}
+
+// CSVStream writes properties to a CSV file one at a time, e.g. from a
+// ScrapeStream channel. Rows reach a temp file beside the target while the
+// crawl is still running, so a crash only loses the rows since the last flush
+// and never the previous file. It is safe for concurrent use.
+type CSVStream struct {
+	// FlushEvery flushes after this many buffered rows
+	FlushEvery int
+	// FlushInterval flushes on the next row once this long has passed since the last flush
+	FlushInterval time.Duration
+
+	repo      *CSVRepository
+	mu        sync.Mutex
+	file      *os.File
+	writer    *csv.Writer
+	pending   int
+	lastFlush time.Time
+}
+
+// OpenStream starts a streaming session on a temp copy of the repository's
+// file, honouring Append and the formatting options. The header is written
+// once, only when the file is empty. Close the stream to flush the remaining
+// rows and replace the file with the temp copy.
+func (r *CSVRepository) OpenStream() (*CSVStream, error) {
+	file, writer, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	// the header is the first thing a partial file needs
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("write csv header: %w", err)
+	}
+
+	return &CSVStream{
+		FlushEvery:    10,
+		FlushInterval: 5 * time.Second,
+		repo:          r,
+		file:          file,
+		writer:        writer,
+		lastFlush:     time.Now(),
+	}, nil
+}
 
+// AppendOne buffers one row, flushing it to disk if FlushEvery rows are
+// pending or FlushInterval has passed.
+func (s *CSVStream) AppendOne(ctx context.Context, p models.Property) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.Write(s.repo.record(p)); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	s.pending++
+	if s.pending >= s.FlushEvery || time.Since(s.lastFlush) >= s.FlushInterval {
+		return s.flush()
+	}
 	return nil
 }
+
+// Flush writes any buffered rows to the file.
+func (s *CSVStream) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush()
+}
+
+// flush writes buffered rows. Callers must hold s.mu.
+func (s *CSVStream) flush() error {
+	s.writer.Flush()
+	s.pending = 0
+	s.lastFlush = time.Now()
+	if err := s.writer.Error(); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	return nil
+}
+
+// Close flushes the remaining rows and renames the temp file over the
+// repository's file. If anything fails the temp file is removed and the
+// previous file is left as it was.
+func (s *CSVStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp := s.file.Name()
+	defer os.Remove(tmp) // no-op once renamed
+
+	if err := s.flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("sync %s: %w", tmp, err)
+	}
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("close csv: %w", err)
+	}
+	// CreateTemp makes the file 0600; match what os.Create would have given
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		return fmt.Errorf("chmod %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.repo.filePath); err != nil {
+		return fmt.Errorf("rename to %s: %w", s.repo.filePath, err)
+	}
+	return nil
+}
+
+// ExistingURLs reports nothing as stored; the file is an export, not a store to dedupe against.
+func (r *CSVRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+// StaleURLs reports nothing, since the file is never read back.
+func (r *CSVRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	return nil, nil
+}
+
+// Close closes the stream opened by Save in Stream mode, moving its rows into
+// place. Otherwise it does nothing: Save replaces the file on every call.
+func (r *CSVRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stream == nil {
+		return nil
+	}
+	err := r.stream.Close()
+	r.stream = nil
+	return err
+}
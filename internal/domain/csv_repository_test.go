@@ -0,0 +1,225 @@
+package domain
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"scraping-airbnb/models"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCSVRepositoryQuotesAwkwardFields(t *testing.T) {
+	description := "Bright loft; \"quiet\" street,\nnear the river"
+	path := filepath.Join(t.TempDir(), "out.csv")
+
+	repo := NewCSVRepository(path)
+	repo.Delimiter = ';'
+	repo.UseCRLF = true
+
+	err := repo.Save(context.Background(), []models.Property{
+		{Title: "Loft", Price: 120, Location: "Paris, France", URL: "https://airbnb.com/rooms/1", Description: description},
+	})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), "\r\n") {
+		t.Errorf("rows do not end with CRLF: %q", data)
+	}
+
+	r := csv.NewReader(strings.NewReader(string(data)))
+	r.Comma = ';'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("reading back csv: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want header + 1 row", len(records))
+	}
+	if got := records[1][5]; got != description {
+		t.Errorf("description = %q, want %q", got, description)
+	}
+}
+
+func TestCSVRepositoryWritesPriceTypeAndRoom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+
+	err := NewCSVRepository(path).Save(context.Background(), []models.Property{
+		{RoomID: "1", URL: "https://airbnb.com/rooms/1", Price: 120, PriceType: models.PriceTypeNightly, Nights: 5},
+		{RoomID: "2", URL: "https://airbnb.com/rooms/2", Price: 600, PriceType: models.PriceTypeTotal},
+	})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("reading back csv: %v", err)
+	}
+
+	want := [][]string{
+		{"RoomID", "PriceType", "Nights"},
+		{"1", "nightly", "5"},
+		{"2", "total", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i, rec := range records {
+		if got := rec[len(rec)-3:]; !slices.Equal(got, want[i]) {
+			t.Errorf("record %d ends with %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestCSVRepositoryAppendWritesHeaderOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	repo := NewCSVRepository(path)
+	repo.Append = true
+
+	for _, url := range []string{"https://airbnb.com/rooms/1", "https://airbnb.com/rooms/2"} {
+		if err := repo.Save(context.Background(), []models.Property{{URL: url}}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("reading back csv: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "Title" || records[1][0] == "Title" || records[2][0] == "Title" {
+		t.Errorf("records = %v, want one header followed by 2 rows", records)
+	}
+}
+
+func TestCSVStreamFlushesRowsAsTheyArrive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if err := os.WriteFile(path, []byte("previous\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := NewCSVRepository(path).OpenStream()
+	if err != nil {
+		t.Fatalf("OpenStream() error = %v", err)
+	}
+	stream.FlushEvery = 2
+
+	lines := func(name string) int {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(string(data), "\n")
+	}
+
+	ctx := context.Background()
+	for i, url := range []string{"https://airbnb.com/rooms/1", "https://airbnb.com/rooms/2", "https://airbnb.com/rooms/3"} {
+		if err := stream.AppendOne(ctx, models.Property{Title: "Loft", URL: url}); err != nil {
+			t.Fatalf("AppendOne(%d) error = %v", i, err)
+		}
+	}
+	// header plus the first two rows are in the temp file; the third is
+	// still buffered and the previous file is untouched, as after a crash
+	tmp := stream.file.Name()
+	if got := lines(tmp); got != 3 {
+		t.Errorf("lines in temp file before Close = %d, want 3", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "previous\n" {
+		t.Errorf("file before Close = %q, want the previous content", data)
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := lines(path); got != 4 {
+		t.Errorf("lines on disk after Close = %d, want 4", got)
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("temp file still there after Close: %v", err)
+	}
+}
+
+func TestCSVRepositoryKeepsEverySavedBatch(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "out.csv")
+		repo := NewCSVRepository(path)
+		repo.Stream = stream
+
+		ctx := context.Background()
+		for _, url := range []string{"https://airbnb.com/rooms/1", "https://airbnb.com/rooms/2"} {
+			if err := repo.Save(ctx, []models.Property{{URL: url}}); err != nil {
+				t.Fatalf("Stream=%v: Save() error = %v", stream, err)
+			}
+		}
+		if err := repo.Close(); err != nil {
+			t.Fatalf("Stream=%v: Close() error = %v", stream, err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		if err != nil {
+			t.Fatalf("Stream=%v: reading back csv: %v", stream, err)
+		}
+		if len(records) != 3 || records[0][0] != "Title" || records[1][3] != "https://airbnb.com/rooms/1" || records[2][3] != "https://airbnb.com/rooms/2" {
+			t.Errorf("Stream=%v: records = %v, want a header and both saved rows", stream, records)
+		}
+	}
+}
+
+func TestCSVRepositoryReplacesPreviousRunsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if err := os.WriteFile(path, []byte("previous\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewCSVRepository(path).Save(context.Background(), []models.Property{{URL: "https://airbnb.com/rooms/1"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "previous") {
+		t.Errorf("file = %q, want the previous run's content replaced", data)
+	}
+}
+
+func TestCSVRepositorySaveURLsWritesOnePerLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.csv")
+	repo := NewCSVRepository(path)
+
+	urls := []string{"https://www.airbnb.com/rooms/1", "https://www.airbnb.com/rooms/2"}
+	if err := repo.SaveURLs(context.Background(), urls); err != nil {
+		t.Fatalf("SaveURLs() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "URL\n" + strings.Join(urls, "\n") + "\n"
+	if string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,307 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"scraping-airbnb/models"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// elasticMapping types the fields Kibana filters and aggregates on; the rest
+// are mapped dynamically.
+const elasticMapping = `{
+  "mappings": {
+    "properties": {
+      "platform":      {"type": "keyword"},
+      "title":         {"type": "text"},
+      "price":         {"type": "float"},
+      "price_type":    {"type": "keyword"},
+      "nights":        {"type": "integer"},
+      "location":      {"type": "text", "fields": {"raw": {"type": "keyword"}}},
+      "url":           {"type": "keyword"},
+      "rating":        {"type": "float"},
+      "description":   {"type": "text"},
+      "property_type": {"type": "keyword"},
+      "coordinates":   {"type": "geo_point"},
+      "scraped_at":    {"type": "date"}
+    }
+  }
+}`
+
+// elasticMaxResults caps StaleURLs to Elasticsearch's default result window.
+const elasticMaxResults = 10000
+
+type ElasticRepository struct {
+	client *elasticsearch.Client
+	index  string
+}
+
+// elasticDoc is the indexed form of a property.
+type elasticDoc struct {
+	Platform     string     `json:"platform"`
+	Title        string     `json:"title"`
+	Price        float32    `json:"price"`
+	PriceType    string     `json:"price_type,omitempty"`
+	Nights       int        `json:"nights,omitempty"`
+	Location     string     `json:"location"`
+	URL          string     `json:"url"`
+	Rating       float32    `json:"rating"`
+	Description  string     `json:"description"`
+	PropertyType string     `json:"property_type"`
+	Coordinates  *geoPoint  `json:"coordinates,omitempty"`
+	ScrapedAt    *time.Time `json:"scraped_at,omitempty"`
+}
+
+type geoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// elasticSetupTimeout bounds connecting to the cluster and creating the index.
+const elasticSetupTimeout = 30 * time.Second
+
+// NewElasticRepository connects to the cluster at addresses and creates index
+// with the property mapping if it does not exist yet. Setup gives up after
+// elasticSetupTimeout, or when ctx is done.
+func NewElasticRepository(ctx context.Context, addresses []string, index string) (*ElasticRepository, error) {
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: addresses})
+	if err != nil {
+		return nil, fmt.Errorf("create elasticsearch client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, elasticSetupTimeout)
+	defer cancel()
+
+	res, err := client.Info(client.Info.WithContext(ctx))
+	if err := responseError("ping elasticsearch", res, err); err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	res, err = client.Indices.Exists([]string{index}, client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("check index %s: %w", index, err)
+	}
+	res.Body.Close()
+	switch res.StatusCode {
+	case 200:
+	case 404:
+		res, err = client.Indices.Create(index,
+			client.Indices.Create.WithContext(ctx),
+			client.Indices.Create.WithBody(strings.NewReader(elasticMapping)))
+		if err := responseError("create index "+index, res, err); err != nil {
+			return nil, err
+		}
+		res.Body.Close()
+	default:
+		return nil, fmt.Errorf("check index %s: %s", index, res.Status())
+	}
+
+	return &ElasticRepository{client: client, index: index}, nil
+}
+
+// elasticID derives a stable document id from the listing url, so re-scrapes
+// overwrite the same document.
+func elasticID(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func toElasticDoc(p models.Property) elasticDoc {
+	doc := elasticDoc{
+		Platform:     p.Platform,
+		Title:        p.Title,
+		Price:        p.Price,
+		PriceType:    string(p.PriceType),
+		Nights:       p.Nights,
+		Location:     p.Location,
+		URL:          p.URL,
+		Rating:       p.Rating,
+		Description:  p.Description,
+		PropertyType: string(p.PropertyType),
+	}
+	if p.Latitude != 0 || p.Longitude != 0 {
+		doc.Coordinates = &geoPoint{Lat: p.Latitude, Lon: p.Longitude}
+	}
+	if !p.ScrapedAt.IsZero() {
+		doc.ScrapedAt = &p.ScrapedAt
+	}
+	return doc
+}
+
+// Save indexes every property in a single bulk request, keyed on the hashed url.
+func (r *ElasticRepository) Save(ctx context.Context, properties []models.Property) error {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, p := range properties {
+		action := map[string]any{"index": map[string]any{"_index": r.index, "_id": elasticID(p.URL)}}
+		if err := enc.Encode(action); err != nil {
+			return fmt.Errorf("encode bulk action: %w", err)
+		}
+		if err := enc.Encode(toElasticDoc(p)); err != nil {
+			return fmt.Errorf("encode property %s: %w", p.URL, err)
+		}
+	}
+
+	res, err := r.client.Bulk(&body, r.client.Bulk.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("bulk index: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("bulk index: %s", res.String())
+	}
+
+	// a 200 response can still carry per-document failures
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	failed := 0
+	var first string
+	for _, item := range result.Items {
+		for _, op := range item {
+			if len(op.Error) > 0 {
+				if failed == 0 {
+					first = string(op.Error)
+				}
+				failed++
+			}
+		}
+	}
+	return fmt.Errorf("bulk index: %d of %d documents failed, first: %s", failed, len(properties), first)
+}
+
+// ExistingURLs returns the subset of urls that already have a document.
+func (r *ElasticRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(urls) == 0 {
+		return existing, nil
+	}
+
+	ids := make([]string, len(urls))
+	byID := make(map[string]string, len(urls))
+	for i, u := range urls {
+		ids[i] = elasticID(u)
+		byID[ids[i]] = u
+	}
+	query, err := json.Marshal(map[string]any{"ids": ids})
+	if err != nil {
+		return nil, fmt.Errorf("encode existing urls: %w", err)
+	}
+
+	res, err := r.client.Mget(bytes.NewReader(query),
+		r.client.Mget.WithContext(ctx),
+		r.client.Mget.WithIndex(r.index),
+		r.client.Mget.WithSource("false"))
+	if err := responseError("query existing urls", res, err); err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var result struct {
+		Docs []struct {
+			ID    string `json:"_id"`
+			Found bool   `json:"found"`
+		} `json:"docs"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("read existing urls: %w", err)
+	}
+	for _, d := range result.Docs {
+		if d.Found {
+			existing[byID[d.ID]] = true
+		}
+	}
+
+	return existing, nil
+}
+
+// StaleURLs returns listings last scraped before the given time, or never
+// timestamped, up to the first 10,000 matches.
+func (r *ElasticRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	query, err := json.Marshal(map[string]any{
+		"_source": []string{"url"},
+		"query": map[string]any{
+			"bool": map[string]any{
+				"should": []any{
+					map[string]any{"range": map[string]any{"scraped_at": map[string]any{"lt": before}}},
+					map[string]any{"bool": map[string]any{"must_not": map[string]any{"exists": map[string]any{"field": "scraped_at"}}}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode stale query: %w", err)
+	}
+
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(r.index),
+		r.client.Search.WithBody(bytes.NewReader(query)),
+		r.client.Search.WithSize(elasticMaxResults))
+	if err := responseError("query stale urls", res, err); err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					URL string `json:"url"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("read stale urls: %w", err)
+	}
+
+	urls := make([]string, 0, len(result.Hits.Hits))
+	for _, h := range result.Hits.Hits {
+		urls = append(urls, h.Source.URL)
+	}
+	return urls, nil
+}
+
+// Close does nothing; the client holds no persistent connections to release.
+func (r *ElasticRepository) Close() error {
+	return nil
+}
+
+// responseError turns a failed request or an error status into an error. On
+// failure the response body is closed; on success the caller must close it.
+func responseError(op string, res *esapi.Response, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if res.IsError() {
+		defer res.Body.Close()
+		msg, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("%s: %s: %s", op, res.Status(), bytes.TrimSpace(msg))
+	}
+	return nil
+}
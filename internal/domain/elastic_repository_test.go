@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"scraping-airbnb/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeElastic serves the few Elasticsearch endpoints ElasticRepository uses.
+// Documents indexed through _bulk are kept by id.
+type fakeElastic struct {
+	// existsStatus answers the index exists check
+	existsStatus int
+
+	mu      sync.Mutex
+	created bool
+	docs    map[string]elasticDoc
+}
+
+func newFakeElastic(t *testing.T, existsStatus int) (*fakeElastic, *httptest.Server) {
+	t.Helper()
+	f := &fakeElastic{existsStatus: existsStatus, docs: make(map[string]elasticDoc)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeElastic) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case req.Method == http.MethodGet && req.URL.Path == "/":
+		fmt.Fprint(w, `{"version": {"number": "8.15.0"}}`)
+	case req.Method == http.MethodHead && req.URL.Path == "/properties":
+		w.WriteHeader(f.existsStatus)
+	case req.Method == http.MethodPut && req.URL.Path == "/properties":
+		f.created = true
+		fmt.Fprint(w, `{"acknowledged": true}`)
+	case req.URL.Path == "/_bulk":
+		// action and document lines alternate
+		lines := bufio.NewScanner(req.Body)
+		for lines.Scan() {
+			var action struct {
+				Index struct {
+					ID string `json:"_id"`
+				} `json:"index"`
+			}
+			json.Unmarshal(lines.Bytes(), &action)
+			lines.Scan()
+			var doc elasticDoc
+			json.Unmarshal(lines.Bytes(), &doc)
+			f.docs[action.Index.ID] = doc
+		}
+		fmt.Fprint(w, `{"errors": false, "items": []}`)
+	case req.URL.Path == "/properties/_mget":
+		var query struct {
+			IDs []string `json:"ids"`
+		}
+		json.NewDecoder(req.Body).Decode(&query)
+		docs := make([]map[string]any, len(query.IDs))
+		for i, id := range query.IDs {
+			_, found := f.docs[id]
+			docs[i] = map[string]any{"_id": id, "found": found}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"docs": docs})
+	case req.URL.Path == "/properties/_search":
+		var query struct {
+			Query struct {
+				Bool struct {
+					Should []struct {
+						Range struct {
+							ScrapedAt struct {
+								LT time.Time `json:"lt"`
+							} `json:"scraped_at"`
+						} `json:"range"`
+					} `json:"should"`
+				} `json:"bool"`
+			} `json:"query"`
+		}
+		json.NewDecoder(req.Body).Decode(&query)
+		before := query.Query.Bool.Should[0].Range.ScrapedAt.LT
+		var hits []map[string]any
+		for _, doc := range f.docs {
+			if doc.ScrapedAt == nil || doc.ScrapedAt.Before(before) {
+				hits = append(hits, map[string]any{"_source": map[string]string{"url": doc.URL}})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"hits": map[string]any{"hits": hits}})
+	default:
+		http.Error(w, `{"error": "unexpected request"}`, http.StatusBadRequest)
+	}
+}
+
+func TestElasticRepositoryCreatesMissingIndex(t *testing.T) {
+	fake, srv := newFakeElastic(t, http.StatusNotFound)
+	if _, err := NewElasticRepository(context.Background(), []string{srv.URL}, "properties"); err != nil {
+		t.Fatalf("NewElasticRepository() error = %v", err)
+	}
+	if !fake.created {
+		t.Error("missing index was not created")
+	}
+}
+
+func TestElasticRepositoryRejectsUnexpectedExistsStatus(t *testing.T) {
+	fake, srv := newFakeElastic(t, http.StatusForbidden)
+	_, err := NewElasticRepository(context.Background(), []string{srv.URL}, "properties")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("NewElasticRepository() error = %v, want the 403 reported", err)
+	}
+	if fake.created {
+		t.Error("index created although its existence could not be checked")
+	}
+}
+
+func TestElasticRepositorySaveAndLookUp(t *testing.T) {
+	_, srv := newFakeElastic(t, http.StatusOK)
+	repo, err := NewElasticRepository(context.Background(), []string{srv.URL}, "properties")
+	if err != nil {
+		t.Fatalf("NewElasticRepository() error = %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	err = repo.Save(ctx, []models.Property{
+		{Title: "Loft", URL: "https://www.airbnb.com/rooms/1", ScrapedAt: now.Add(-48 * time.Hour)},
+		{Title: "Villa", URL: "https://www.airbnb.com/rooms/2", ScrapedAt: now},
+	})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	existing, err := repo.ExistingURLs(ctx, []string{"https://www.airbnb.com/rooms/1", "https://www.airbnb.com/rooms/3"})
+	if err != nil {
+		t.Fatalf("ExistingURLs() error = %v", err)
+	}
+	if len(existing) != 1 || !existing["https://www.airbnb.com/rooms/1"] {
+		t.Errorf("ExistingURLs() = %v, want only rooms/1", existing)
+	}
+
+	stale, err := repo.StaleURLs(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("StaleURLs() error = %v", err)
+	}
+	if len(stale) != 1 || stale[0] != "https://www.airbnb.com/rooms/1" {
+		t.Errorf("StaleURLs() = %v, want only rooms/1", stale)
+	}
+}
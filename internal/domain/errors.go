@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPermanent marks failures that will not succeed on retry. Wrap it
+// (fmt.Errorf("...: %w", ErrPermanent)) to opt an error out of retries.
+var ErrPermanent = errors.New("permanent failure")
+
+// ErrTooManyFailures is returned when a scrape's failure ratio exceeds the
+// configured threshold. It wraps ErrPermanent: re-running the crawl is not
+// expected to help.
+var ErrTooManyFailures = fmt.Errorf("too many urls failed: %w", ErrPermanent)
+
+// ErrSchemaOutdated is returned by PostgresRepository.Save when the database
+// lacks a column Save writes, such as description on a table created before
+// it existed. It wraps ErrPermanent: saves keep failing until the schema is migrated.
+var ErrSchemaOutdated = fmt.Errorf("database schema is out of date: %w", ErrPermanent)
+
+// IsRetryable reports whether err may succeed on a later attempt.
+// Context cancellation, expired deadlines, and ErrPermanent are never retried.
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, ErrPermanent):
+		return false
+	default:
+		return true
+	}
+}
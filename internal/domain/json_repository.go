@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"scraping-airbnb/models"
+	"sync"
+	"time"
+)
+
+// JSONArrayRepository writes every saved property into one JSON array file.
+// The file is rewritten atomically on each Save (temp file, then rename), so
+// readers only ever see a complete array.
+type JSONArrayRepository struct {
+	filePath string
+	// Indent is repeated per nesting level ("  " by default; empty writes compact JSON)
+	Indent string
+
+	mu    sync.Mutex
+	saved []models.Property
+}
+
+func NewJSONArrayRepository(filePath string) *JSONArrayRepository {
+	return &JSONArrayRepository{
+		filePath: filePath,
+		Indent:   "  ",
+	}
+}
+
+// Save adds properties to those saved earlier in the run and rewrites the file
+// with all of them.
+func (r *JSONArrayRepository) Save(ctx context.Context, properties []models.Property) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := append(r.saved[:len(r.saved):len(r.saved)], properties...)
+	if all == nil {
+		all = []models.Property{}
+	}
+	if err := r.write(all); err != nil {
+		return err
+	}
+	r.saved = all
+	return nil
+}
+
+// SaveURLs replaces the file with urls as a JSON array of strings.
+func (r *JSONArrayRepository) SaveURLs(ctx context.Context, urls []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if urls == nil {
+		urls = []string{}
+	}
+	return r.write(urls)
+}
+
+// write replaces the file with v encoded as JSON.
+func (r *JSONArrayRepository) write(v any) error {
+	data, err := json.MarshalIndent(v, "", r.Indent)
+	if r.Indent == "" {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	return atomicWrite(r.filePath, func(w io.Writer) error {
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("write json: %w", err)
+		}
+		return nil
+	})
+}
+
+// ExistingURLs reports nothing as stored; the file is rewritten each run.
+func (r *JSONArrayRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+// StaleURLs reports nothing, since listings are not kept across runs.
+func (r *JSONArrayRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	return nil, nil
+}
+
+// Close does nothing; every Save leaves a complete file behind.
+func (r *JSONArrayRepository) Close() error {
+	return nil
+}
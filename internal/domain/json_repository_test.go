@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"scraping-airbnb/models"
+	"strings"
+	"testing"
+)
+
+func TestJSONArrayRepositoryWritesWholeArray(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.json")
+	repo := NewJSONArrayRepository(path)
+	repo.Indent = "\t"
+
+	ctx := context.Background()
+	if err := repo.Save(ctx, []models.Property{{Title: "Loft", Reviews: []string{"Great"}}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := repo.Save(ctx, []models.Property{{Title: "Cabin"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []models.Property
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v", err)
+	}
+	if len(got) != 2 || got[0].Title != "Loft" || got[0].Reviews[0] != "Great" || got[1].Title != "Cabin" {
+		t.Errorf("read back %+v", got)
+	}
+	if !strings.Contains(string(data), "\n\t{") {
+		t.Errorf("output not indented with tabs: %q", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"scraping-airbnb/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+type MongoRepository struct {
+	client     *mongo.Client
+	collection *mongo.Collection
+}
+
+// NewMongoRepository connects to MongoDB and ensures a unique index on url
+// so re-scrapes update existing documents instead of duplicating them.
+func NewMongoRepository(ctx context.Context, uri, dbName, collection string) (*MongoRepository, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, fmt.Errorf("connect mongo: %w", err)
+	}
+
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("ping mongo: %w", err)
+	}
+
+	coll := client.Database(dbName).Collection(collection)
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "url", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("create url index: %w", err)
+	}
+
+	return &MongoRepository{client: client, collection: coll}, nil
+}
+
+// Save upserts every property keyed on its url in a single unordered bulk write.
+func (r *MongoRepository) Save(ctx context.Context, properties []models.Property) error {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(properties))
+	for _, p := range properties {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"url": p.URL}).
+			SetReplacement(p).
+			SetUpsert(true))
+	}
+
+	if _, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("bulk upsert: %w", err)
+	}
+
+	return nil
+}
+
+// ExistingURLs returns the subset of urls that already have a document.
+func (r *MongoRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(urls) == 0 {
+		return existing, nil
+	}
+
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"url": bson.M{"$in": urls}},
+		options.Find().SetProjection(bson.M{"url": 1, "_id": 0}))
+	if err != nil {
+		return nil, fmt.Errorf("find existing urls: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			URL string `bson:"url"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode existing url: %w", err)
+		}
+		existing[doc.URL] = true
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("read existing urls: %w", err)
+	}
+
+	return existing, nil
+}
+
+// StaleURLs returns listings whose scrapedat is missing or before the given time.
+func (r *MongoRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"scrapedat": bson.M{"$lt": before}},
+			bson.M{"scrapedat": bson.M{"$exists": false}},
+		}},
+		options.Find().SetProjection(bson.M{"url": 1, "_id": 0}))
+	if err != nil {
+		return nil, fmt.Errorf("find stale urls: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var urls []string
+	for cursor.Next(ctx) {
+		var doc struct {
+			URL string `bson:"url"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode stale url: %w", err)
+		}
+		urls = append(urls, doc.URL)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("read stale urls: %w", err)
+	}
+
+	return urls, nil
+}
+
+// Close disconnects the underlying client.
+func (r *MongoRepository) Close() error {
+	return r.client.Disconnect(context.Background())
+}
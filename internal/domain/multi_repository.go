@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scraping-airbnb/models"
+	"time"
+)
+
+// MultiRepository saves to several repositories at once, e.g. Postgres plus a
+// CSV backup. Lookups (ExistingURLs, StaleURLs) go to the first repository,
+// which is treated as the primary store.
+type MultiRepository struct {
+	repos []PropertyRepository
+	// FailFast stops at the first failed Save; otherwise every repository is
+	// tried and the failures are joined into one error.
+	FailFast bool
+}
+
+func NewMultiRepository(repos ...PropertyRepository) *MultiRepository {
+	return &MultiRepository{repos: repos}
+}
+
+// Save fans properties out to every repository.
+func (r *MultiRepository) Save(ctx context.Context, properties []models.Property) error {
+	var errs []error
+	for i, repo := range r.repos {
+		if err := repo.Save(ctx, properties); err != nil {
+			err = fmt.Errorf("repository %d (%T): %w", i, repo, err)
+			if r.FailFast {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ExistingURLs asks the primary repository.
+func (r *MultiRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	if len(r.repos) == 0 {
+		return map[string]bool{}, nil
+	}
+	return r.repos[0].ExistingURLs(ctx, urls)
+}
+
+// StaleURLs asks the primary repository.
+func (r *MultiRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	if len(r.repos) == 0 {
+		return nil, nil
+	}
+	return r.repos[0].StaleURLs(ctx, before)
+}
+
+// Close closes every repository, joining any failures.
+func (r *MultiRepository) Close() error {
+	var errs []error
+	for i, repo := range r.repos {
+		if err := repo.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("repository %d (%T): %w", i, repo, err))
+		}
+	}
+	return errors.Join(errs...)
+}
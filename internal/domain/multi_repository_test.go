@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"scraping-airbnb/models"
+	"testing"
+)
+
+// countingRepository counts Save calls and fails them with err.
+type countingRepository struct {
+	NoOpRepository
+	calls int
+	err   error
+}
+
+func (r *countingRepository) Save(ctx context.Context, properties []models.Property) error {
+	r.calls++
+	return r.err
+}
+
+func TestMultiRepositorySave(t *testing.T) {
+	errA, errB := errors.New("postgres down"), errors.New("disk full")
+
+	t.Run("continue on error", func(t *testing.T) {
+		a, b, c := &countingRepository{err: errA}, &countingRepository{err: errB}, &countingRepository{}
+		err := NewMultiRepository(a, b, c).Save(context.Background(), nil)
+		if !errors.Is(err, errA) || !errors.Is(err, errB) {
+			t.Errorf("Save() error = %v, want both failures", err)
+		}
+		if c.calls != 1 {
+			t.Errorf("last repository saved %d times, want 1", c.calls)
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		a, b := &countingRepository{err: errA}, &countingRepository{}
+		multi := NewMultiRepository(a, b)
+		multi.FailFast = true
+		if err := multi.Save(context.Background(), nil); !errors.Is(err, errA) {
+			t.Errorf("Save() error = %v, want %v", err, errA)
+		}
+		if b.calls != 0 {
+			t.Errorf("second repository saved %d times after a failure, want 0", b.calls)
+		}
+	})
+}
@@ -3,63 +3,494 @@ package domain
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	schema "scraping-airbnb/db"
 	"scraping-airbnb/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultBatchSize is the number of rows committed per transaction by Save.
+const DefaultBatchSize = 500
+
+// Conflict handling for PostgresRepository.OnConflict.
+const (
+	// ConflictUpsert overwrites a stored listing with the newly scraped one.
+	ConflictUpsert = "upsert"
+	// ConflictIgnore keeps the stored listing and drops the new one, for
+	// append-only auditing.
+	ConflictIgnore = "ignore"
 )
 
 type PostgresRepository struct {
 	db *sql.DB
+	// BatchSize caps how many rows are written per transaction (<= 0 means DefaultBatchSize).
+	BatchSize int
+	// RecordHistory also appends each price to price_history, which unlike
+	// properties is never overwritten.
+	RecordHistory bool
+	// OnConflict is how a listing whose room is already stored is handled:
+	// ConflictUpsert (the default, also used when empty) or ConflictIgnore.
+	OnConflict string
+	// MaxRetries is how many times a batch whose transaction lost a
+	// serialization conflict or deadlock to a concurrent writer is retried.
+	MaxRetries int
+	// RetryDelay is the wait before the first retry; it doubles on each one.
+	RetryDelay time.Duration
 }
 
 func NewPostgresRepository(db *sql.DB) *PostgresRepository {
-	return &PostgresRepository{db: db}
+	return &PostgresRepository{
+		db:         db,
+		BatchSize:  DefaultBatchSize,
+		OnConflict: ConflictUpsert,
+		MaxRetries: 3,
+		RetryDelay: 100 * time.Millisecond,
+	}
 }
 
-// Save inserts properties in a single transaction using a prepared statement.
+// Save upserts properties (or, with ConflictIgnore, inserts the new ones) in
+// batches of BatchSize, committing each batch in its own transaction. A batch
+// rolled back by a serialization failure or deadlock is retried up to
+// MaxRetries times, so several instances can write to the same table at once.
+// On failure the error reports how many rows were already persisted.
 func (r *PostgresRepository) Save(ctx context.Context, properties []models.Property) error {
 	if len(properties) == 0 {
 		return nil
 	}
 
+	size := r.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	saved := 0
+	for start := 0; start < len(properties); start += size {
+		end := min(start+size, len(properties))
+		if err := r.saveBatchWithRetry(ctx, properties[start:end]); err != nil {
+			if sqlState(err) == sqlStateUndefinedColumn {
+				err = fmt.Errorf("%w: %w; run PostgresRepository.Migrate (the scraper does on connect) or apply db/init.sql",
+					ErrSchemaOutdated, err)
+			}
+			return fmt.Errorf("saved %d of %d properties: %w", saved, len(properties), err)
+		}
+		saved = end
+	}
+
+	return nil
+}
+
+// saveBatchWithRetry runs saveBatch, retrying with backoff while it fails with
+// a transient concurrency conflict.
+func (r *PostgresRepository) saveBatchWithRetry(ctx context.Context, properties []models.Property) error {
+	delay := r.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err := r.saveBatch(ctx, properties)
+		if err == nil || !isTxConflict(err) || attempt >= r.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// SQLSTATE codes of transactions aborted by a concurrent one, which succeed
+// when run again.
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// sqlStateUndefinedColumn is the SQLSTATE of a statement naming a column the
+// table doesn't have.
+const sqlStateUndefinedColumn = "42703"
+
+// sqlState returns the SQLSTATE code of a Postgres error in err's chain, or "".
+func sqlState(err error) pq.ErrorCode {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return ""
+	}
+	return pqErr.Code
+}
+
+// isTxConflict reports whether err is a serialization failure or deadlock.
+func isTxConflict(err error) bool {
+	code := sqlState(err)
+	return code == sqlStateSerializationFailure || code == sqlStateDeadlockDetected
+}
+
+// maxParams is Postgres' limit on bind parameters in a single statement.
+const maxParams = 65535
+
+// propertyColumns lists the columns written by Save, in bind order.
+var propertyColumns = []string{"room_id", "platform", "title", "price", "price_type", "nights", "location", "url", "rating", "description", "property_type", "latitude", "longitude", "scraped_at", "reviews"}
+
+// migrateLockKey is the advisory lock Migrate holds, so instances starting
+// together don't apply the same migration twice.
+const migrateLockKey = 5_480_211
+
+// Migrate runs db/init.sql, the same schema docker-compose initialises a
+// fresh database with, in one transaction: it creates the tables Save writes
+// to if they are absent and adds any column of the current Property schema
+// an existing properties table is missing. It then applies each of
+// db.Migrations not yet recorded in schema_migrations. It is safe to run on
+// every start.
+func (r *PostgresRepository) Migrate(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrateLockKey); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("lock migrations: %w", err)
+	}
+	// without arguments the whole script goes out as one simple query
+	if _, err := tx.ExecContext(ctx, schema.Init); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migrate: %w", err)
+	}
+	for _, m := range schema.Migrations {
+		if err := applyMigration(ctx, tx, m); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// applyMigration runs m and records it in schema_migrations, unless it is
+// already recorded there.
+func applyMigration(ctx context.Context, tx *sql.Tx, m schema.Migration) error {
+	var applied bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.Version).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("check migration %d: %w", m.Version, err)
+	}
+	if applied {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return fmt.Errorf("migration %d: %w", m.Version, err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.Version); err != nil {
+		return fmt.Errorf("record migration %d: %w", m.Version, err)
+	}
+	return nil
+}
+
+// saveBatch upserts one batch in a single transaction, sending as many rows per
+// INSERT as the bind-parameter limit allows.
+func (r *PostgresRepository) saveBatch(ctx context.Context, properties []models.Property) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO properties (platform, title, price, location, url, rating, description)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (url) DO UPDATE SET
+	// a single statement can't upsert the same room twice
+	properties = dedupeByRoomID(properties)
+
+	// history and date prices are only written for the rooms actually stored
+	var written []models.Property
+	rowsPerStmt := maxParams / len(propertyColumns)
+	for start := 0; start < len(properties); start += rowsPerStmt {
+		end := min(start+rowsPerStmt, len(properties))
+		stored, err := r.insert(ctx, tx, properties[start:end])
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if r.RecordHistory && len(stored) > 0 {
+			query, args := buildHistoryInsert(stored)
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("exec history insert: %w", err)
+			}
+		}
+		written = append(written, stored...)
+	}
+
+	rows := datePriceRows(written)
+	rowsPerStmt = maxParams / datePriceColumns
+	for start := 0; start < len(rows); start += rowsPerStmt {
+		end := min(start+rowsPerStmt, len(rows))
+		query, args := buildDatePriceInsert(rows[start:end])
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("exec date price insert: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// insert writes properties with one statement and returns those it stored:
+// all of them, or with ConflictIgnore only the rooms that were new.
+func (r *PostgresRepository) insert(ctx context.Context, tx *sql.Tx, properties []models.Property) ([]models.Property, error) {
+	query, args := buildInsert(properties, r.OnConflict)
+	if r.OnConflict != ConflictIgnore {
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, fmt.Errorf("exec insert: %w", err)
+		}
+		return properties, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("exec insert: %w", err)
+	}
+	defer rows.Close()
+	inserted := make(map[string]bool, len(properties))
+	for rows.Next() {
+		var roomID string
+		if err := rows.Scan(&roomID); err != nil {
+			return nil, fmt.Errorf("scan inserted room: %w", err)
+		}
+		inserted[roomID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("exec insert: %w", err)
+	}
+
+	stored := make([]models.Property, 0, len(inserted))
+	for _, p := range properties {
+		if inserted[p.RoomID] {
+			stored = append(stored, p)
+		}
+	}
+	return stored, nil
+}
+
+// buildInsert returns a multi-row INSERT ... ON CONFLICT statement and its
+// arguments. Rooms already stored are updated, or with ConflictIgnore left as
+// they are; that statement returns the room_id of each row it inserted.
+func buildInsert(properties []models.Property, onConflict string) (string, []any) {
+	var b strings.Builder
+	args := make([]any, 0, len(properties)*len(propertyColumns))
+
+	b.WriteString("INSERT INTO properties (")
+	b.WriteString(strings.Join(propertyColumns, ", "))
+	b.WriteString(") VALUES ")
+
+	for i, p := range properties {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for j := range propertyColumns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "$%d", len(args)+j+1)
+		}
+		b.WriteString(")")
+		args = append(args, p.RoomID, p.Platform, p.Title, p.Price, p.PriceType, p.Nights, p.Location, p.URL, p.Rating, p.Description, p.PropertyType, p.Latitude, p.Longitude, p.ScrapedAt, reviewsJSON(p.Reviews))
+	}
+
+	if onConflict == ConflictIgnore {
+		b.WriteString(`
+		ON CONFLICT (room_id) DO NOTHING
+		RETURNING room_id`)
+		return b.String(), args
+	}
+
+	b.WriteString(`
+		ON CONFLICT (room_id) DO UPDATE SET
+			url = EXCLUDED.url,
 			title = EXCLUDED.title,
 			price = EXCLUDED.price,
+			price_type = EXCLUDED.price_type,
+			nights = EXCLUDED.nights,
 			location = EXCLUDED.location,
 			rating = EXCLUDED.rating,
-			description = EXCLUDED.description
-	`)
+			description = EXCLUDED.description,
+			property_type = EXCLUDED.property_type,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			scraped_at = EXCLUDED.scraped_at,
+			reviews = COALESCE(EXCLUDED.reviews, properties.reviews)`)
+
+	return b.String(), args
+}
+
+// reviewsJSON encodes reviews for the JSONB column; none is stored as NULL so
+// a run without reviews keeps those already saved.
+func reviewsJSON(reviews []string) any {
+	if len(reviews) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(reviews)
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("prepare stmt: %w", err)
+		return nil
+	}
+	return string(data)
+}
+
+// buildHistoryInsert returns a multi-row INSERT into price_history and its arguments.
+func buildHistoryInsert(properties []models.Property) (string, []any) {
+	var b strings.Builder
+	args := make([]any, 0, len(properties)*3)
+
+	b.WriteString("INSERT INTO price_history (url, price, scraped_at) VALUES ")
+	for i, p := range properties {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "($%d, $%d, $%d)", len(args)+1, len(args)+2, len(args)+3)
+		args = append(args, p.URL, p.Price, p.ScrapedAt)
 	}
-	defer stmt.Close()
 
+	return b.String(), args
+}
+
+// datePriceColumns is the number of columns written per date_prices row.
+const datePriceColumns = 5
+
+// datePriceRow is one date_prices row: a listing's price for one stay.
+type datePriceRow struct {
+	roomID    string
+	price     models.DatePrice
+	scrapedAt time.Time
+}
+
+// datePriceRows flattens the date prices of every property.
+func datePriceRows(properties []models.Property) []datePriceRow {
+	var rows []datePriceRow
 	for _, p := range properties {
-		if _, err := stmt.ExecContext(ctx,
-			p.Platform,
-			p.Title,
-			p.Price,
-			p.Location,
-			p.URL,
-			p.Rating,
-			p.Description,
-		); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("exec insert: %w", err)
+		for _, dp := range p.DatePrices {
+			rows = append(rows, datePriceRow{roomID: p.RoomID, price: dp, scrapedAt: p.ScrapedAt})
 		}
 	}
+	return rows
+}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
+// buildDatePriceInsert returns a multi-row INSERT into date_prices and its arguments.
+func buildDatePriceInsert(rows []datePriceRow) (string, []any) {
+	var b strings.Builder
+	args := make([]any, 0, len(rows)*datePriceColumns)
+
+	b.WriteString("INSERT INTO date_prices (room_id, check_in, check_out, price, scraped_at) VALUES ")
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&b, "($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5)
+		args = append(args, row.roomID, row.price.CheckIn, row.price.CheckOut, row.price.Price, row.scrapedAt)
 	}
 
-	return nil
+	return b.String(), args
+}
+
+// dedupeByRoomID keeps the last occurrence of each room, preserving first-seen order.
+func dedupeByRoomID(properties []models.Property) []models.Property {
+	index := make(map[string]int, len(properties))
+	out := make([]models.Property, 0, len(properties))
+	for _, p := range properties {
+		if i, ok := index[p.RoomID]; ok {
+			out[i] = p
+			continue
+		}
+		index[p.RoomID] = len(out)
+		out = append(out, p)
+	}
+	return out
+}
+
+// ExistingURLs returns the subset of urls that already have a row.
+func (r *PostgresRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(urls) == 0 {
+		return existing, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT url FROM properties WHERE url = ANY($1)`, pq.Array(urls))
+	if err != nil {
+		return nil, fmt.Errorf("query existing urls: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, fmt.Errorf("scan existing url: %w", err)
+		}
+		existing[url] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read existing urls: %w", err)
+	}
+
+	return existing, nil
+}
+
+// StaleURLs returns listings last scraped before the given time, including
+// rows written before scraped_at was recorded.
+func (r *PostgresRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT url FROM properties WHERE scraped_at IS NULL OR scraped_at < $1`, before)
+	if err != nil {
+		return nil, fmt.Errorf("query stale urls: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, fmt.Errorf("scan stale url: %w", err)
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read stale urls: %w", err)
+	}
+
+	return urls, nil
+}
+
+// PriceHistory returns every recorded price for url, oldest first.
+func (r *PostgresRepository) PriceHistory(ctx context.Context, url string) ([]models.PricePoint, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT price, scraped_at FROM price_history WHERE url = $1 ORDER BY scraped_at`, url)
+	if err != nil {
+		return nil, fmt.Errorf("query price history: %w", err)
+	}
+	defer rows.Close()
+
+	var points []models.PricePoint
+	for rows.Next() {
+		var p models.PricePoint
+		if err := rows.Scan(&p.Price, &p.ScrapedAt); err != nil {
+			return nil, fmt.Errorf("scan price point: %w", err)
+		}
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read price history: %w", err)
+	}
+
+	return points, nil
+}
+
+// Close closes the database connection pool.
+func (r *PostgresRepository) Close() error {
+	return r.db.Close()
 }
@@ -0,0 +1,394 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	schema "scraping-airbnb/db"
+	"scraping-airbnb/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+func TestIsTxConflict(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("exec insert: %w", &pq.Error{Code: "40001"}), true},
+		{fmt.Errorf("commit tx: %w", &pq.Error{Code: "40P01"}), true},
+		{&pq.Error{Code: "23505"}, false}, // unique_violation
+		{errors.New("connection refused"), false},
+	} {
+		if got := isTxConflict(tc.err); got != tc.want {
+			t.Errorf("isTxConflict(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestBuildInsertConflictModes(t *testing.T) {
+	properties := []models.Property{{RoomID: "1", Title: "new title", Price: 120}}
+
+	upsert, _ := buildInsert(properties, ConflictUpsert)
+	if !strings.Contains(upsert, "ON CONFLICT (room_id) DO UPDATE SET") {
+		t.Errorf("upsert does not update stored rows:\n%s", upsert)
+	}
+	if empty, _ := buildInsert(properties, ""); empty != upsert {
+		t.Errorf("empty mode is not upsert:\n%s", empty)
+	}
+
+	// ignore must not touch any column of a stored row
+	ignore, args := buildInsert(properties, ConflictIgnore)
+	if !strings.Contains(ignore, "ON CONFLICT (room_id) DO NOTHING") {
+		t.Errorf("ignore does not skip stored rows:\n%s", ignore)
+	}
+	if strings.Contains(ignore, "UPDATE") || strings.Contains(ignore, "EXCLUDED") {
+		t.Errorf("ignore overwrites stored rows:\n%s", ignore)
+	}
+	if len(args) != len(propertyColumns) {
+		t.Errorf("got %d args, want %d", len(args), len(propertyColumns))
+	}
+}
+
+func TestSaveSplitsInsertsAtTheParameterLimit(t *testing.T) {
+	rowsPerStmt := maxParams / len(propertyColumns)
+	properties := make([]models.Property, rowsPerStmt+1)
+	for i := range properties {
+		properties[i] = models.Property{RoomID: fmt.Sprint(i), URL: fmt.Sprintf("https://www.airbnb.com/rooms/%d", i)}
+	}
+
+	db := &fakeDB{}
+	repo := NewPostgresRepository(sql.OpenDB(db))
+	repo.BatchSize = len(properties)
+	if err := repo.Save(context.Background(), properties); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var inserts []fakeStmt
+	for _, s := range db.stmts {
+		if strings.HasPrefix(s.query, "INSERT INTO properties") {
+			inserts = append(inserts, s)
+		}
+	}
+	if len(inserts) != 2 {
+		t.Fatalf("got %d inserts, want 2", len(inserts))
+	}
+
+	// each statement numbers its placeholders from $1 up to its own arg count
+	for i, want := range []int{rowsPerStmt, 1} {
+		stmt := inserts[i]
+		if got := len(stmt.args) / len(propertyColumns); got != want {
+			t.Errorf("insert %d has %d rows, want %d", i, got, want)
+		}
+		last := len(stmt.args)
+		if last > maxParams {
+			t.Errorf("insert %d binds %d parameters, over the limit of %d", i, last, maxParams)
+		}
+		if !strings.Contains(stmt.query, fmt.Sprintf("$%d)", last)) || strings.Contains(stmt.query, fmt.Sprintf("$%d,", last+1)) {
+			t.Errorf("insert %d placeholders do not end at $%d", i, last)
+		}
+	}
+	if id := inserts[1].args[0].Value; id != fmt.Sprint(rowsPerStmt) {
+		t.Errorf("second insert starts with room %v, want %d", id, rowsPerStmt)
+	}
+}
+
+func TestBuildInsertNumbersEachRow(t *testing.T) {
+	query, args := buildInsert([]models.Property{{RoomID: "1"}, {RoomID: "2"}}, ConflictUpsert)
+
+	n := len(propertyColumns)
+	if len(args) != 2*n || args[n] != "2" {
+		t.Fatalf("args = %v, want the second row's from index %d", args, n)
+	}
+	second := fmt.Sprintf("), ($%d, $%d,", n+1, n+2)
+	if !strings.Contains(query, second) || !strings.Contains(query, fmt.Sprintf("$%d)", 2*n)) {
+		t.Errorf("query does not number the second row from $%d:\n%s", n+1, query)
+	}
+}
+
+func TestSchemaAddsEveryPropertyColumn(t *testing.T) {
+	for _, col := range propertyColumns {
+		if !strings.Contains(schema.Init, "ADD COLUMN IF NOT EXISTS "+col+" ") {
+			t.Errorf("db/init.sql never adds column %s to an older table", col)
+		}
+	}
+}
+
+func TestSQLState(t *testing.T) {
+	err := fmt.Errorf("exec insert: %w", &pq.Error{Code: sqlStateUndefinedColumn, Message: `column "description" does not exist`})
+	if got := sqlState(err); got != sqlStateUndefinedColumn {
+		t.Errorf("sqlState = %q, want %q", got, sqlStateUndefinedColumn)
+	}
+	if got := sqlState(errors.New("connection refused")); got != "" {
+		t.Errorf("sqlState of a non-Postgres error = %q", got)
+	}
+}
+
+func TestSaveIgnoreRecordsHistoryOnlyForInsertedRooms(t *testing.T) {
+	db := &fakeDB{stored: map[string]bool{"1": true}}
+	repo := NewPostgresRepository(sql.OpenDB(db))
+	repo.OnConflict = ConflictIgnore
+	repo.RecordHistory = true
+
+	checkIn := time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC)
+	stay := []models.DatePrice{{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 2), Price: 200}}
+	err := repo.Save(context.Background(), []models.Property{
+		{RoomID: "1", URL: "https://www.airbnb.com/rooms/1", Price: 90, DatePrices: stay},
+		{RoomID: "2", URL: "https://www.airbnb.com/rooms/2", Price: 120, DatePrices: stay},
+	})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	history := db.args("INSERT INTO price_history")
+	if len(history) != 1 || history[0][0] != "https://www.airbnb.com/rooms/2" {
+		t.Errorf("price_history args = %v, want only the new room 2", history)
+	}
+	datePrices := db.args("INSERT INTO date_prices")
+	if len(datePrices) != 1 || datePrices[0][0] != "2" {
+		t.Errorf("date_prices args = %v, want only the new room 2", datePrices)
+	}
+}
+
+// fakeDB is a database/sql connector that records statements. An INSERT ...
+// RETURNING room_id returns the rooms of its rows that are not in stored.
+type fakeDB struct {
+	stored map[string]bool
+
+	mu    sync.Mutex
+	stmts []fakeStmt
+}
+
+type fakeStmt struct {
+	query string
+	args  []driver.NamedValue
+}
+
+// args returns the arguments of every statement starting with prefix.
+func (db *fakeDB) args(prefix string) [][]driver.Value {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var out [][]driver.Value
+	for _, s := range db.stmts {
+		if !strings.HasPrefix(s.query, prefix) {
+			continue
+		}
+		values := make([]driver.Value, len(s.args))
+		for i, a := range s.args {
+			values[i] = a.Value
+		}
+		out = append(out, values)
+	}
+	return out
+}
+
+func (db *fakeDB) record(query string, args []driver.NamedValue) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.stmts = append(db.stmts, fakeStmt{query, args})
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query, args)
+	rows := &fakeRows{}
+	for i := 0; i < len(args); i += len(propertyColumns) {
+		if id := args[i].Value.(string); !c.db.stored[id] {
+			rows.roomIDs = append(rows.roomIDs, id)
+		}
+	}
+	return rows, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct{ roomIDs []string }
+
+func (r *fakeRows) Columns() []string { return []string{"room_id"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.roomIDs) == 0 {
+		return io.EOF
+	}
+	dest[0], r.roomIDs = r.roomIDs[0], r.roomIDs[1:]
+	return nil
+}
+
+// startPostgres runs a throwaway Postgres container, the version
+// docker-compose uses, and returns a migrated repository on it. It skips
+// under -short or when Docker isn't available.
+func startPostgres(tb testing.TB) (*PostgresRepository, *sql.DB) {
+	tb.Helper()
+	if testing.Short() {
+		tb.Skip("needs a Postgres container")
+	}
+	provider, err := testcontainers.ProviderDocker.GetProvider()
+	if err == nil {
+		err = provider.Health(context.Background())
+	}
+	if err != nil {
+		tb.Skipf("needs Docker for a Postgres container: %v", err)
+	}
+
+	ctx := context.Background()
+	ctr, err := postgres.Run(ctx, "postgres:15",
+		postgres.WithDatabase("db_name"),
+		postgres.WithUsername("db_user"),
+		postgres.WithPassword("db_password"),
+		postgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(tb, ctr)
+	if err != nil {
+		tb.Fatalf("start postgres: %v", err)
+	}
+	dsn, err := ctr.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	repo := NewPostgresRepository(db)
+	if err := repo.Migrate(ctx); err != nil {
+		tb.Fatalf("Migrate: %v", err)
+	}
+	return repo, db
+}
+
+// BenchmarkSave compares Save's multi-row inserts with one INSERT per row, the
+// approach it replaced, on a Postgres container (see startPostgres):
+//
+//	go test -run '^$' -bench BenchmarkSave ./internal/domain
+func BenchmarkSave(b *testing.B) {
+	repo, db := startPostgres(b)
+	ctx := context.Background()
+
+	properties := make([]models.Property, 1000)
+	for i := range properties {
+		properties[i] = models.Property{
+			RoomID:    fmt.Sprint(i),
+			Platform:  "Airbnb",
+			Title:     "Loft",
+			Price:     120,
+			URL:       fmt.Sprintf("https://www.airbnb.com/rooms/%d", i),
+			ScrapedAt: time.Now(),
+		}
+	}
+	truncate := func(b *testing.B) {
+		b.StopTimer()
+		if _, err := db.ExecContext(ctx, `TRUNCATE properties`); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+	}
+
+	b.Run("multi-row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			truncate(b)
+			if err := repo.Save(ctx, properties); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("per-row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			truncate(b)
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, p := range properties {
+				query, args := buildInsert([]models.Property{p}, ConflictUpsert)
+				if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+					tx.Rollback()
+					b.Fatal(err)
+				}
+			}
+			if err := tx.Commit(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestSaveIgnoreLeavesStoredRowsUntouched(t *testing.T) {
+	repo, db := startPostgres(t)
+	ctx := context.Background()
+
+	scrapedAt := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	stored := models.Property{
+		RoomID:    "1",
+		Platform:  "Airbnb",
+		Title:     "Loft",
+		Price:     120,
+		PriceType: models.PriceTypeNightly,
+		URL:       "https://www.airbnb.com/rooms/1",
+		Rating:    4.5,
+		ScrapedAt: scrapedAt,
+	}
+	if err := repo.Save(ctx, []models.Property{stored}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	repo.OnConflict = ConflictIgnore
+	changed := stored
+	changed.Title = "Renovated loft"
+	changed.Price = 150
+	changed.PriceType = models.PriceTypeTotal
+	changed.URL = "https://www.airbnb.com/rooms/1?adults=2"
+	changed.Rating = 4
+	changed.ScrapedAt = scrapedAt.Add(24 * time.Hour)
+	added := models.Property{RoomID: "2", Platform: "Airbnb", URL: "https://www.airbnb.com/rooms/2", ScrapedAt: scrapedAt}
+	if err := repo.Save(ctx, []models.Property{changed, added}); err != nil {
+		t.Fatalf("Save with ConflictIgnore: %v", err)
+	}
+
+	var got models.Property
+	err := db.QueryRowContext(ctx,
+		`SELECT title, price, price_type, url, rating, scraped_at FROM properties WHERE room_id = $1`, stored.RoomID).
+		Scan(&got.Title, &got.Price, &got.PriceType, &got.URL, &got.Rating, &got.ScrapedAt)
+	if err != nil {
+		t.Fatalf("read back room 1: %v", err)
+	}
+	if got.Title != stored.Title || got.Price != stored.Price || got.PriceType != stored.PriceType ||
+		got.URL != stored.URL || got.Rating != stored.Rating || !got.ScrapedAt.Equal(stored.ScrapedAt) {
+		t.Errorf("room 1 = %+v, want it as first saved: %+v", got, stored)
+	}
+
+	var rows int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM properties`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 2 {
+		t.Errorf("properties has %d rows, want the stored room 1 and the new room 2", rows)
+	}
+}
@@ -3,8 +3,22 @@ package domain
 import (
 	"context"
 	"scraping-airbnb/models"
+	"time"
 )
 
 type PropertyRepository interface {
 	Save(ctx context.Context, property []models.Property) error
+	// ExistingURLs reports which of urls are already stored.
+	ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error)
+	// StaleURLs returns stored listings last scraped before the given time.
+	StaleURLs(ctx context.Context, before time.Time) ([]string, error)
+	// Close flushes anything buffered and releases connections. The
+	// repository must not be used afterwards.
+	Close() error
+}
+
+// URLListWriter is implemented by file-like repositories that can also store
+// a plain list of listing URLs, as produced by a collect-only run.
+type URLListWriter interface {
+	SaveURLs(ctx context.Context, urls []string) error
 }
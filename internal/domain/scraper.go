@@ -2,9 +2,102 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"scraping-airbnb/models"
+	"strings"
 )
 
 type Scraper interface {
+	// Scrape returns every property it could extract. When some URLs fail,
+	// the partial results are returned together with a *ScrapeError.
 	Scrape(ctx context.Context, baseUrl string) ([]models.Property, error)
-}
\ No newline at end of file
+	// ScrapeStream sends each property to out as soon as it is extracted,
+	// returning once the crawl is finished. It does not close out.
+	ScrapeStream(ctx context.Context, baseUrl string, out chan<- models.Property) error
+	// ScrapeURLs extracts the given listing URLs directly, without discovery.
+	// Partial failures are reported the same way as Scrape.
+	ScrapeURLs(ctx context.Context, urls []string) ([]models.Property, error)
+	// ScrapeSearch scrapes one search results page without homepage discovery.
+	ScrapeSearch(ctx context.Context, searchURL string) ([]models.Property, error)
+}
+
+// URLFilter narrows a batch of listing URLs before they are extracted.
+type URLFilter func(ctx context.Context, urls []string) []string
+
+// URLLookup reports which of urls are already stored, like
+// PropertyRepository.ExistingURLs.
+type URLLookup func(ctx context.Context, urls []string) (map[string]bool, error)
+
+// FilterableScraper is a Scraper that can skip listing URLs before extracting them.
+type FilterableScraper interface {
+	Scraper
+	SetURLFilter(filter URLFilter)
+	// SetKnownURLs installs the lookup used to tell whether a results page
+	// holds only stored listings (ScraperConfig.StopAtKnownPage).
+	SetKnownURLs(lookup URLLookup)
+}
+
+// URLError records why a single URL failed in a given scrape stage.
+type URLError struct {
+	Stage string
+	URL   string
+	Err   error
+	// Attempts made before giving up (0 if unknown)
+	Attempts int
+}
+
+func (e *URLError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Stage, e.URL, e.Err)
+}
+
+func (e *URLError) Unwrap() error {
+	return e.Err
+}
+
+// ScrapeError summarises the per-URL failures of a scrape that still produced results.
+type ScrapeError struct {
+	Failures []*URLError
+	// Attempted is how many URLs the scrape tried across all stages (0 if unknown).
+	Attempted int
+}
+
+// FailureRatio is the share of attempted URLs that failed, or 1 when Attempted is unknown.
+func (e *ScrapeError) FailureRatio() float64 {
+	if e.Attempted <= 0 {
+		return 1
+	}
+	return float64(len(e.Failures)) / float64(e.Attempted)
+}
+
+func (e *ScrapeError) Error() string {
+	byStage := make(map[string]int)
+	var stages []string
+	for _, f := range e.Failures {
+		if byStage[f.Stage] == 0 {
+			stages = append(stages, f.Stage)
+		}
+		byStage[f.Stage]++
+	}
+
+	parts := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		parts = append(parts, fmt.Sprintf("%s=%d", stage, byStage[stage]))
+	}
+	return fmt.Sprintf("%d urls failed (%s)", len(e.Failures), strings.Join(parts, ", "))
+}
+
+func (e *ScrapeError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f
+	}
+	return errs
+}
+
+// AsScrapeError reports whether err is a partial-failure summary and returns it.
+func AsScrapeError(err error) (*ScrapeError, bool) {
+	var se *ScrapeError
+	ok := errors.As(err, &se)
+	return se, ok
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"scraping-airbnb/models"
+	"time"
+)
+
+// StdoutRepository pretty-prints properties instead of persisting them.
+// Useful for dry runs while debugging selectors.
+type StdoutRepository struct {
+	w io.Writer
+	// SummaryOnly prints just the property count instead of every property
+	SummaryOnly bool
+}
+
+func NewStdoutRepository() *StdoutRepository {
+	return &StdoutRepository{w: os.Stdout}
+}
+
+func (r *StdoutRepository) Save(ctx context.Context, properties []models.Property) error {
+	fmt.Fprintf(r.w, "%d properties scraped\n", len(properties))
+	if r.SummaryOnly {
+		return nil
+	}
+
+	for i, p := range properties {
+		fmt.Fprintf(r.w, "\n#%d %s\n", i+1, p.Title)
+		fmt.Fprintf(r.w, "  Platform:    %s\n", p.Platform)
+		fmt.Fprintf(r.w, "  Price:       $%.2f\n", p.Price)
+		fmt.Fprintf(r.w, "  Rating:      %.2f\n", p.Rating)
+		fmt.Fprintf(r.w, "  Type:        %s\n", p.PropertyType)
+		fmt.Fprintf(r.w, "  Location:    %s\n", p.Location)
+		fmt.Fprintf(r.w, "  Coordinates: %.6f, %.6f\n", p.Latitude, p.Longitude)
+		fmt.Fprintf(r.w, "  URL:         %s\n", p.URL)
+		fmt.Fprintf(r.w, "  Description: %d chars\n", len(p.Description))
+		fmt.Fprintf(r.w, "  Scraped at:  %s\n", p.ScrapedAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// SaveURLs prints urls one per line, ready to pipe into -urls-file -.
+func (r *StdoutRepository) SaveURLs(ctx context.Context, urls []string) error {
+	for _, u := range urls {
+		fmt.Fprintln(r.w, u)
+	}
+	return nil
+}
+
+// ExistingURLs reports nothing as stored, since nothing is persisted.
+func (r *StdoutRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+// StaleURLs reports nothing, since nothing is persisted.
+func (r *StdoutRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	return nil, nil
+}
+
+// Close does nothing; every Save writes straight through.
+func (r *StdoutRepository) Close() error {
+	return nil
+}
+
+// NoOpRepository discards everything it is given.
+type NoOpRepository struct{}
+
+func NewNoOpRepository() *NoOpRepository {
+	return &NoOpRepository{}
+}
+
+func (r *NoOpRepository) Save(ctx context.Context, properties []models.Property) error {
+	return nil
+}
+
+func (r *NoOpRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+func (r *NoOpRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	return nil, nil
+}
+
+func (r *NoOpRepository) Close() error {
+	return nil
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"scraping-airbnb/models"
+	"time"
+)
+
+// WebhookRepository POSTs each batch of properties as a JSON array to an HTTP
+// endpoint, for event-driven pipelines. It stores nothing itself.
+type WebhookRepository struct {
+	url    string
+	token  string
+	client *http.Client
+	// Timeout bounds each POST attempt.
+	Timeout time.Duration
+	// MaxRetries is how many times a 5xx response or transport error is retried.
+	MaxRetries int
+	// RetryDelay is the wait before the first retry; it doubles on each one.
+	RetryDelay time.Duration
+}
+
+// NewWebhookRepository posts to url, sending token as a bearer token when non-empty.
+func NewWebhookRepository(url, token string) *WebhookRepository {
+	return &WebhookRepository{
+		url:        url,
+		token:      token,
+		client:     &http.Client{},
+		Timeout:    30 * time.Second,
+		MaxRetries: 3,
+		RetryDelay: time.Second,
+	}
+}
+
+// Save POSTs properties, retrying server errors. 4xx responses fail at once.
+func (r *WebhookRepository) Save(ctx context.Context, properties []models.Property) error {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(properties)
+	if err != nil {
+		return fmt.Errorf("encode properties: %w", err)
+	}
+
+	delay := r.RetryDelay
+	for attempt := 0; ; attempt++ {
+		retry, err := r.post(ctx, body)
+		if err == nil || !retry || attempt >= r.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// post sends one request and reports whether a failure is worth retrying.
+func (r *WebhookRepository) post(ctx context.Context, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("post webhook: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	switch {
+	case res.StatusCode >= 500:
+		return true, fmt.Errorf("post webhook: %s", res.Status)
+	case res.StatusCode >= 300:
+		return false, fmt.Errorf("post webhook: %s: %w", res.Status, ErrPermanent)
+	}
+	return false, nil
+}
+
+// ExistingURLs reports nothing as stored, since the endpoint is write-only.
+func (r *WebhookRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+// StaleURLs reports nothing, since the endpoint is write-only.
+func (r *WebhookRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	return nil, nil
+}
+
+// Close does nothing; connections are pooled by the HTTP client.
+func (r *WebhookRepository) Close() error {
+	return nil
+}
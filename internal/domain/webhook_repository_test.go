@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"scraping-airbnb/models"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWebhookRepositoryRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan []models.Property, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := calls.Add(1)
+		if auth := req.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("Authorization = %q, want bearer token", auth)
+		}
+		if n == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var got []models.Property
+		json.NewDecoder(req.Body).Decode(&got)
+		received <- got
+	}))
+	defer srv.Close()
+
+	repo := NewWebhookRepository(srv.URL, "secret")
+	repo.RetryDelay = 0
+	props := []models.Property{{Title: "Loft", URL: "https://airbnb.com/rooms/1"}}
+	if err := repo.Save(context.Background(), props); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("webhook called %d times, want 2", n)
+	}
+	if got := <-received; len(got) != 1 || got[0].URL != props[0].URL {
+		t.Errorf("webhook received %v, want %v", got, props)
+	}
+}
+
+func TestWebhookRepositoryDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	repo := NewWebhookRepository(srv.URL, "")
+	repo.RetryDelay = 0
+	err := repo.Save(context.Background(), []models.Property{{URL: "https://airbnb.com/rooms/1"}})
+	if !errors.Is(err, ErrPermanent) {
+		t.Errorf("Save() error = %v, want permanent", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("webhook called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,67 @@
+// Package telemetry exposes scraper metrics to Prometheus.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	PropertiesScraped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "properties_scraped_total",
+		Help: "Listings successfully extracted.",
+	})
+	PropertiesFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "properties_failed_total",
+		Help: "Listings whose extraction failed.",
+	})
+	ExtractionDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "extraction_duration_seconds",
+		Help:    "Time spent extracting a single listing page.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 8), // 1s .. 128s
+	})
+	ActiveWorkers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "active_workers",
+		Help: "Product workers currently extracting a listing.",
+	})
+)
+
+// shutdownTimeout bounds how long Serve waits for in-flight scrapes of /metrics.
+const shutdownTimeout = 5 * time.Second
+
+// Serve exposes /metrics on addr until ctx is cancelled, then shuts the server down.
+func Serve(ctx context.Context, addr string, logger *slog.Logger) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("metrics server listening", "addr", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	logger.Info("metrics server stopped")
+	return nil
+}
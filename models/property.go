@@ -1,13 +1,64 @@
 package models
 
+import "time"
+
 type Property struct {
 	ID       int64
+	RoomID   string
 	Platform string
 	Title    string
+	// Price as shown, divided into a nightly price when labelled as the total
+	// for Nights; the low end when the listing shows a range such as "$120 – $180"
 	Price    float32
+	// What Price is: nightly, or the stay total when the page gave no nights
+	// to divide it by ("" when the label was not recognised; Price is then
+	// undivided)
+	PriceType PriceType
+	// Nights the shown price covered, from the "for N nights" breakdown (0 = not shown)
+	Nights   int
 	Location string
 	URL      string
 	Rating   float32
 	Description  string
+	PropertyType PropertyType
+	Latitude     float64
+	Longitude    float64
+	ScrapedAt    time.Time
+	// Review texts, up to ScraperConfig.MaxReviews
+	Reviews []string
+	// Nightly prices for the configured date ranges, if any
+	DatePrices []DatePrice
 }
 
+// PropertyType is the normalized kind of space a listing offers.
+type PropertyType string
+
+// Property types as shown by Airbnb near the listing title.
+const (
+	PropertyTypeEntireHome  PropertyType = "Entire home"
+	PropertyTypePrivateRoom PropertyType = "Private room"
+	PropertyTypeSharedRoom  PropertyType = "Shared room"
+	PropertyTypeHotelRoom   PropertyType = "Hotel room"
+	PropertyTypeUnknown     PropertyType = "Unknown"
+)
+
+// PriceType tells whether a price is per night or for the whole stay.
+type PriceType string
+
+const (
+	PriceTypeNightly PriceType = "nightly"
+	PriceTypeTotal   PriceType = "total"
+)
+
+// DatePrice is a listing's nightly price for one stay.
+type DatePrice struct {
+	CheckIn  time.Time
+	CheckOut time.Time
+	Price    float32
+}
+
+// PricePoint is one recorded nightly price for a listing.
+type PricePoint struct {
+	Price     float32
+	ScrapedAt time.Time
+}
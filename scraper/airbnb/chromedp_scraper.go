@@ -3,11 +3,16 @@ package airbnb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
 	"math/rand"
+	"slices"
+	"strings"
 	"scraping-airbnb/config"
+	"scraping-airbnb/internal/domain"
+	"scraping-airbnb/internal/telemetry"
 	"scraping-airbnb/models"
 	"scraping-airbnb/scraper"
 	"scraping-airbnb/utils"
@@ -15,87 +20,159 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 )
 
 type ChromedpScraper struct {
+	parent       context.Context
+	allocMu      sync.RWMutex
 	allocatorCtx context.Context
+	allocCancel  context.CancelFunc
+	browserCtx   context.Context
+	closeBrowser context.CancelFunc
+	stopWatch    context.CancelFunc
 	cfg          *config.Config
-	rateLimiter  *time.Ticker
-	requestMutex sync.Mutex
+	rateLimiter  *scraper.HostRateLimiters
 	userAgents   []string
+	rngMu        sync.Mutex
+	rng          *rand.Rand
+	stealthRng   *rand.Rand
+	log          *slog.Logger
+	metrics      atomic.Pointer[Metrics]
+	robots       *scraper.RobotsChecker
+	cookieMu     sync.Mutex
+	cookies      map[cookieKey]*network.Cookie
+	urlFilter    domain.URLFilter
+	knownURLs    domain.URLLookup
+	site         scraper.SiteScraper
+	progress     scraper.ProgressReporter
+	// extract fetches one listing page: extractProperty, unless a test swaps it
+	extract func(ctx context.Context, tabs *scraper.TabPool, url string) (models.Property, error)
 }
 
-// NewChromedpScraper returns a ChromedpScraper using the default configuration.
-
-func NewChromedpScraper(parent context.Context) *ChromedpScraper {
-	cfg := config.Default()
-	log.SetFlags(log.LstdFlags)
-	log.Printf("chromedp scraper created")
-
-	// initialize rate limiter
-	var ticker *time.Ticker
-	if cfg.Stealth.MaxRequestsPerSecond > 0 {
-		interval := time.Duration(float64(time.Second) / float64(cfg.Stealth.MaxRequestsPerSecond))
-		ticker = time.NewTicker(interval)
+// NewChromedpScraper returns a ChromedpScraper that crawls site using the given
+// configuration. A nil site means Airbnb; a nil logger falls back to slog.Default().
+func NewChromedpScraper(parent context.Context, site scraper.SiteScraper, cfg *config.Config, logger *slog.Logger) *ChromedpScraper {
+	if site == nil {
+		site = Site{}
+	}
+	if logger == nil {
+		logger = slog.Default()
 	}
+	logger = logger.With("component", "chromedp")
+	logger.Info("chromedp scraper created")
 
 	s := &ChromedpScraper{
-		allocatorCtx: scraper.NewAllocator(parent, &cfg.Browser),
+		parent:       parent,
 		cfg:          cfg,
-		rateLimiter:  ticker,
-		userAgents:   config.DefaultUserAgents(),
+		rateLimiter:  scraper.NewHostRateLimiters(&cfg.Stealth, logger),
+		userAgents:   userAgentPool(cfg.Stealth.Device),
+		rng:          rand.New(rand.NewSource(utils.Seed(cfg.Retry.JitterSeed))),
+		stealthRng:   rand.New(rand.NewSource(utils.Seed(cfg.Stealth.Seed))),
+		log:          logger,
+		site:         site,
+		progress:     scraper.NopProgress{},
+	}
+	s.extract = s.extractProperty
+	s.allocatorCtx, s.allocCancel = scraper.NewAllocator(parent, &cfg.Browser)
+	s.metrics.Store(newMetrics())
+	watchCtx, stopWatch := context.WithCancel(parent)
+	s.stopWatch = stopWatch
+	if every := cfg.Debug.TargetCountInterval; every > 0 {
+		go s.watchTargets(watchCtx, every)
+	}
+	s.loadCookies()
+
+	if cfg.Stealth.RespectRobots {
+		s.robots = scraper.NewRobotsChecker(cfg.Browser.UserAgent)
+		logger.Info("robots.txt enforcement enabled")
+	} else {
+		logger.Warn("robots.txt enforcement disabled")
 	}
 
 	// log stealth settings
 	if cfg.Stealth.RandomDelayEnabled {
-		log.Printf("stealth: random delays enabled (%v-%v)", cfg.Stealth.RandomDelayMin, cfg.Stealth.RandomDelayMax)
+		logger.Info("stealth: random delays enabled", "min", cfg.Stealth.RandomDelayMin, "max", cfg.Stealth.RandomDelayMax)
 	}
 	if cfg.Stealth.RandomUserAgentEnabled {
-		log.Printf("stealth: random user agent enabled")
+		logger.Info("stealth: random user agent enabled")
+	}
+	if cfg.Stealth.Device != "" && cfg.Stealth.Device != config.DeviceDesktop {
+		logger.Info("stealth: emulating mobile devices", "device", cfg.Stealth.Device)
 	}
 	if cfg.Stealth.MaxRequestsPerSecond > 0 {
-		log.Printf("stealth: rate limit enabled (%.d req/sec)", cfg.Stealth.MaxRequestsPerSecond)
+		logger.Info("stealth: rate limit enabled",
+			"requests_per_second", cfg.Stealth.MaxRequestsPerSecond, "adaptive", cfg.Stealth.AdaptiveRateLimit)
 	}
 
 	return s
 }
 
 // runWithRetry executes chromedp.Run with exponential backoff retries.
+// When RetryOnCaptcha is enabled, attempts after a captcha switch the tab to a fresh user agent.
 func (s *ChromedpScraper) runWithRetry(ctx context.Context, actions ...chromedp.Action) error {
+	captcha := false
 	return s.retryWithBackoff(ctx, func() error {
-		return chromedp.Run(ctx, actions...)
+		run := actions
+		if captcha {
+			ua := s.getRandomUserAgent()
+			s.log.Info("captcha seen; switching user agent", "user_agent", ua)
+			run = append([]chromedp.Action{s.userAgentAction(ua)}, actions...)
+		}
+		err := chromedp.Run(ctx, run...)
+		if errors.Is(err, ErrCaptchaDetected) {
+			captcha = true
+		}
+		return err
 	})
 }
 
 // retryWithBackoff executes fn with exponential backoff.
 func (s *ChromedpScraper) retryWithBackoff(ctx context.Context, fn func() error) error {
 	maxRetries := s.cfg.Retry.MaxRetries
-	initialBackoff := s.cfg.Retry.InitialBackoff
-	maxBackoff := s.cfg.Retry.MaxBackoff
 
 	var lastErr error
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			log.Printf("[chromedp-retry] attempt #%d of %d...", attempt+1, maxRetries+1)
+			s.log.Info("retrying", "attempt", attempt+1, "max_attempts", maxRetries+1)
+			s.ensureBrowser()
 		}
 
 		if err := fn(); err == nil {
 			if attempt > 0 {
-				log.Printf("[chromedp-retry] ✅ attempt #%d succeeded", attempt+1)
+				s.log.Info("retry succeeded", "attempt", attempt+1)
 			}
 			return nil
 		} else {
 			lastErr = err
 		}
 
+		if !s.isRetryable(lastErr) {
+			s.log.Warn("attempt failed permanently; not retrying", "attempt", attempt+1, "error", lastErr)
+			return &attemptsError{attempt + 1, fmt.Errorf("chromedp failed permanently: %w", lastErr)}
+		}
+
 		if attempt < maxRetries {
-			backoff := time.Duration(float64(initialBackoff) * math.Pow(2, float64(attempt)))
-			if backoff > maxBackoff {
-				backoff = maxBackoff
+			// the budget is shared by every operation in the run
+			if !s.metrics.Load().takeRetry(s.cfg.Retry.GlobalBudget) {
+				s.log.Warn("retry budget exhausted; not retrying", "budget", s.cfg.Retry.GlobalBudget, "error", lastErr)
+				return &attemptsError{attempt + 1, fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, lastErr)}
+			}
+
+			backoff := s.backoff(attempt)
+			// bot checks need a longer cool-down than ordinary transient failures
+			if errors.Is(lastErr, ErrCaptchaDetected) && s.cfg.Retry.CaptchaBackoff > backoff {
+				backoff = s.cfg.Retry.CaptchaBackoff
+			}
+			// a 429 says how long to wait; trust it, within reason
+			var limited *scraper.RateLimitedError
+			if errors.As(lastErr, &limited) && limited.RetryAfter > 0 {
+				backoff = min(limited.RetryAfter, s.cfg.Retry.MaxBackoff*retryAfterCapFactor)
 			}
 
-			log.Printf("[chromedp-retry] attempt #%d failed: %v; waiting %v before retry", attempt+1, lastErr, backoff)
+			s.log.Warn("attempt failed; backing off", "attempt", attempt+1, "error", lastErr, "backoff", backoff)
 			select {
 			case <-time.After(backoff):
 				// continue
@@ -105,83 +182,488 @@ func (s *ChromedpScraper) retryWithBackoff(ctx context.Context, fn func() error)
 		}
 	}
 
-	log.Printf("[chromedp-retry] ❌ all %d attempts failed", maxRetries+1)
-	return fmt.Errorf("chromedp failed after %d attempts: %w", maxRetries+1, lastErr)
+	s.log.Error("all attempts failed", "attempts", maxRetries+1, "error", lastErr)
+	return &attemptsError{maxRetries + 1, fmt.Errorf("chromedp failed after %d attempts: %w", maxRetries+1, lastErr)}
+}
+
+// retryAfterCapFactor caps an honoured Retry-After at this many MaxBackoffs,
+// so a hostile or broken server can't park a worker for hours.
+const retryAfterCapFactor = 6
+
+// backoff returns the (optionally jittered) retry delay for attempt.
+// The RNG is shared by all workers, so access is serialized.
+func (s *ChromedpScraper) backoff(attempt int) time.Duration {
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+	return utils.Backoff(&s.cfg.Retry, attempt, s.rng)
+}
+
+// applyRateLimit waits if necessary to respect the max requests per second
+// configured for url's host. It returns early with ctx's error if ctx is done.
+func (s *ChromedpScraper) applyRateLimit(ctx context.Context, url string) error {
+	return s.rateLimiter.For(url).Wait(ctx)
 }
 
-// applyRateLimit waits if necessary to respect the configured max requests per second.
-func (s *ChromedpScraper) applyRateLimit() {
-	if s.rateLimiter == nil {
-		return
+// reportOutcome feeds a page result back to the adaptive rate limiter of url's host.
+// Captchas and failed extractions count as blocking; cancellations are ignored.
+func (s *ChromedpScraper) reportOutcome(url string, err error) {
+	limiter := s.rateLimiter.For(url)
+	switch {
+	case err == nil:
+		limiter.Success()
+	case errors.Is(err, context.Canceled):
+	case errors.Is(err, ErrCaptchaDetected), errors.Is(err, ErrExtraction), isTimeout(err), isRateLimited(err):
+		limiter.Blocked()
 	}
-	s.requestMutex.Lock()
-	defer s.requestMutex.Unlock()
-	<-s.rateLimiter.C
 }
 
 // randomDelay applies a random sleep if stealth mode is enabled.
 func (s *ChromedpScraper) randomDelay() {
+	time.Sleep(s.nextDelay())
+}
+
+// nextDelay picks the next random delay, or 0 when delays are disabled.
+// With DelayNormal, delays follow a Gaussian centred between min and max
+// whose ±3σ spans the range, clamped to it.
+func (s *ChromedpScraper) nextDelay() time.Duration {
 	if !s.cfg.Stealth.RandomDelayEnabled {
-		return
+		return 0
 	}
 	minMs := s.cfg.Stealth.RandomDelayMin.Milliseconds()
 	maxMs := s.cfg.Stealth.RandomDelayMax.Milliseconds()
 	if minMs >= maxMs {
-		return
+		return 0
+	}
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+	if s.cfg.Stealth.DelayDistribution == config.DelayNormal {
+		mean := float64(minMs+maxMs) / 2
+		stddev := float64(maxMs-minMs) / 6
+		randMs := int64(math.Round(mean + s.stealthRng.NormFloat64()*stddev))
+		return time.Duration(min(max(randMs, minMs), maxMs)) * time.Millisecond
 	}
-	randMs := rand.Int63n(maxMs - minMs) + minMs
-	time.Sleep(time.Duration(randMs) * time.Millisecond)
+	randMs := s.stealthRng.Int63n(maxMs-minMs) + minMs
+	return time.Duration(randMs) * time.Millisecond
+}
+
+// SetSeed reseeds the RNG behind random delays and user agent choice, so the
+// same seed yields the same sequence. A seed of 0 seeds from the clock.
+func (s *ChromedpScraper) SetSeed(seed int64) {
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+	s.stealthRng = rand.New(rand.NewSource(utils.Seed(seed)))
+}
+
+// Metrics returns a snapshot of the current (or most recent) run's metrics.
+func (s *ChromedpScraper) Metrics() MetricsSnapshot {
+	m := s.metrics.Load()
+	if m == nil {
+		return MetricsSnapshot{}
+	}
+	return m.Snapshot()
+}
+
+// SetURLFilter installs a filter applied to listing URLs before extraction,
+// e.g. to skip listings that are already stored. It must be called before Scrape.
+func (s *ChromedpScraper) SetURLFilter(filter domain.URLFilter) {
+	s.urlFilter = filter
+}
+
+// SetKnownURLs installs the stored-listing lookup StopAtKnownPage checks each
+// results page against. It must be called before Scrape.
+func (s *ChromedpScraper) SetKnownURLs(lookup domain.URLLookup) {
+	s.knownURLs = lookup
+}
+
+// SetProgressReporter installs p to receive crawl progress events; nil
+// restores the no-op default. It must be called before Scrape.
+func (s *ChromedpScraper) SetProgressReporter(p scraper.ProgressReporter) {
+	if p == nil {
+		p = scraper.NopProgress{}
+	}
+	s.progress = p
+}
+
+// setTabUserAgent overrides the tab's user agent with a pick from the pool, so
+// rotation applies per tab rather than once for the whole allocator.
+func (s *ChromedpScraper) setTabUserAgent() chromedp.Action {
+	return s.userAgentAction(s.getRandomUserAgent())
+}
+
+// userAgentAction switches the tab to ua, with a phone viewport when ua is a
+// mobile user agent so the site actually serves its mobile layout.
+func (s *ChromedpScraper) userAgentAction(ua string) chromedp.Action {
+	return chromedp.Tasks{
+		emulation.SetUserAgentOverride(ua),
+		scraper.EmulateDevice(scraper.IsMobileUserAgent(ua)),
+	}
+}
+
+// setTabHeaders sends StealthConfig.ExtraHeaders with every request of the
+// tab, the way a real browser sends Accept-Language and friends. A configured
+// locale replaces an Accept-Language for another language, so text the site
+// localizes from headers agrees with the URL parameters. CDP replaces the
+// whole header set on each call, hence one action for both.
+func (s *ChromedpScraper) setTabHeaders() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		headers := s.tabHeaders()
+		if len(headers) == 0 {
+			return nil
+		}
+		if err := network.Enable().Do(ctx); err != nil {
+			return err
+		}
+		return network.SetExtraHTTPHeaders(headers).Do(ctx)
+	})
+}
+
+// tabHeaders merges StealthConfig.ExtraHeaders with the locale; see setTabHeaders.
+func (s *ChromedpScraper) tabHeaders() network.Headers {
+	headers := network.Headers{}
+	for name, value := range s.cfg.Stealth.ExtraHeaders {
+		headers[name] = value
+	}
+	if locale := s.cfg.Scraper.Locale; locale != "" {
+		lang := locale
+		for name, value := range headers {
+			if !strings.EqualFold(name, "Accept-Language") {
+				continue
+			}
+			delete(headers, name)
+			// keep e.g. "en-US,en;q=0.9" for locale "en"
+			if v, _ := value.(string); strings.HasPrefix(strings.ToLower(v), strings.ToLower(locale)) {
+				lang = v
+			}
+		}
+		headers["Accept-Language"] = lang
+	}
+	return headers
+}
+
+// maskFingerprint hides headless-Chrome tells from page scripts when
+// StealthConfig.FingerprintMasking is set, with navigator.languages matching
+// the Accept-Language header. Run it once per tab, before the first navigation.
+func (s *ChromedpScraper) maskFingerprint() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if !s.cfg.Stealth.FingerprintMasking {
+			return nil
+		}
+		var acceptLanguage string
+		for name, value := range s.tabHeaders() {
+			if v, ok := value.(string); ok && strings.EqualFold(name, "Accept-Language") {
+				acceptLanguage = v
+			}
+		}
+		return scraper.MaskFingerprint(acceptLanguage).Do(ctx)
+	})
+}
+
+// localURL returns url with the configured locale and currency applied.
+func (s *ChromedpScraper) localURL(url string) string {
+	return s.site.LocalizeURL(url, s.cfg.Scraper.Locale, s.cfg.Scraper.Currency)
+}
+
+// fieldSelectors returns the configured selectors for a product-page field,
+// falling back to the built-in ones when none are configured.
+func (s *ChromedpScraper) fieldSelectors(field string) []string {
+	if selectors := s.cfg.Scraper.Selectors[field]; len(selectors) > 0 {
+		return selectors
+	}
+	return s.site.DefaultSelectors()[field]
+}
+
+// fieldSelector joins a field's selectors into one CSS selector group that
+// matches any of them, for use with WaitVisible.
+func (s *ChromedpScraper) fieldSelector(field string) string {
+	return strings.Join(s.fieldSelectors(field), ", ")
+}
+
+// fieldJS returns the extraction JS for a product-page field.
+func (s *ChromedpScraper) fieldJS(field string) string {
+	// the title h1 can contain visually hidden children; innerText skips them
+	prop := "textContent"
+	if field == config.SelectorTitle {
+		prop = "innerText"
+	}
+	return firstTextJS(s.fieldSelectors(field), prop)
+}
+
+// scrollPage returns the scroll action selected by ScraperConfig.ScrollMode.
+func (s *ChromedpScraper) scrollPage() chromedp.Action {
+	if s.cfg.Scraper.ScrollMode == config.ScrollModeDynamic {
+		return scraper.ScrollUntilStable(&s.cfg.Timing, s.cfg.Scraper.ScrollStep, s.cfg.Scraper.MaxScrollIterations)
+	}
+	return scraper.ScrollToBottom(&s.cfg.Timing, s.cfg.Scraper.ScrollStep)
+}
+
+// userAgentPool returns the user agents rotated through for a
+// StealthConfig.Device profile.
+func userAgentPool(device string) []string {
+	switch device {
+	case config.DeviceMobile:
+		return config.DefaultMobileUserAgents()
+	case config.DeviceMixed:
+		return append(config.DefaultUserAgents(), config.DefaultMobileUserAgents()...)
+	}
+	return config.DefaultUserAgents()
 }
 
 // getRandomUserAgent returns a random user agent from the pool if enabled.
+// Without rotation the configured browser user agent is used, or on the
+// mobile profile the first mobile one.
 func (s *ChromedpScraper) getRandomUserAgent() string {
 	if !s.cfg.Stealth.RandomUserAgentEnabled || len(s.userAgents) == 0 {
+		if s.cfg.Stealth.Device == config.DeviceMobile && len(s.userAgents) > 0 {
+			return s.userAgents[0]
+		}
 		return s.cfg.Browser.UserAgent
 	}
-	return s.userAgents[rand.Intn(len(s.userAgents))]
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+	return s.userAgents[s.stealthRng.Intn(len(s.userAgents))]
 }
 
+// Scrape runs the full crawl and returns every extracted property at once.
+// It is a convenience wrapper around ScrapeStream.
 func (s *ChromedpScraper) Scrape(ctx context.Context, baseURL string) ([]models.Property, error) {
+	return collect(func(out chan<- models.Property) error {
+		return s.ScrapeStream(ctx, baseURL, out)
+	})
+}
+
+// ScrapeURLs extracts the given listing URLs directly, skipping the location
+// and card discovery stages.
+func (s *ChromedpScraper) ScrapeURLs(ctx context.Context, urls []string) ([]models.Property, error) {
+	return collect(func(out chan<- models.Property) error {
+		start := time.Now()
+		s.log.Info("scrape started", "urls", len(urls))
+		s.metrics.Store(newMetrics())
+
+		propertyURLs := s.prepareURLs(ctx, s.dedupe(urls))
+		fetched, failures := s.extractPropertiesWorkerPool(ctx, propertyURLs, s.cfg.Concurrency.ProductWorkers, out)
+
+		s.log.Info("scrape finished",
+			"urls", len(propertyURLs),
+			"fetched", fetched,
+			"failed", len(failures),
+			"duration", time.Since(start))
+
+		if len(failures) > 0 {
+			return &domain.ScrapeError{Failures: failures, Attempted: len(propertyURLs)}
+		}
+		return nil
+	})
+}
+
+// collect runs stream and gathers everything it sends into a slice.
+func collect(stream func(out chan<- models.Property) error) ([]models.Property, error) {
+	out := make(chan models.Property)
+	done := make(chan struct{})
+
+	var property []models.Property
+	go func() {
+		defer close(done)
+		for p := range out {
+			property = append(property, p)
+		}
+	}()
+
+	err := stream(out)
+	close(out)
+	<-done
+
+	return property, err
+}
+
+// prepareURLs drops listing URLs disallowed by robots.txt or by the URL filter
+// and applies the MaxProperties cap. robots.txt is asked about the localized
+// URL, which is the one the tab loads.
+func (s *ChromedpScraper) prepareURLs(ctx context.Context, propertyURLs []string) []string {
+	propertyURLs = slices.DeleteFunc(propertyURLs, func(u string) bool {
+		return !s.allowedByRobots(ctx, s.localURL(u))
+	})
+
+	if s.urlFilter != nil {
+		before := len(propertyURLs)
+		propertyURLs = s.filterCanonical(ctx, propertyURLs)
+		s.log.Info("property urls filtered", "skipped", before-len(propertyURLs), "remaining", len(propertyURLs))
+	}
+
+	if limit := s.cfg.Scraper.MaxProperties; limit > 0 && len(propertyURLs) > limit {
+		s.log.Info("limiting property urls", "limit", limit, "count", len(propertyURLs))
+		propertyURLs = propertyURLs[:limit]
+	}
+
+	return propertyURLs
+}
+
+// filterCanonical runs the URL filter on canonical URLs, since that is how
+// listings are stored, and maps the kept ones back to the discovered URLs.
+// URLs the filter adds (such as stale listings) are navigated as given.
+func (s *ChromedpScraper) filterCanonical(ctx context.Context, urls []string) []string {
+	discovered := make(map[string]string, len(urls))
+	canonical := make([]string, len(urls))
+	for i, u := range urls {
+		canonical[i] = s.site.CanonicalURL(u)
+		discovered[canonical[i]] = u
+	}
+
+	kept := s.urlFilter(ctx, canonical)
+	for i, c := range kept {
+		if u, ok := discovered[c]; ok {
+			kept[i] = u
+		}
+	}
+	return kept
+}
+
+// ScrapeStream runs the full crawl and sends each property to out as soon as
+// it is extracted. It does not close out; the caller owns the channel.
+func (s *ChromedpScraper) ScrapeStream(ctx context.Context, baseURL string, out chan<- models.Property) error {
 
 	start := time.Now()
-	log.Printf("scrape: start %s", baseURL)
+	s.log.Info("scrape started", "url", baseURL)
+	s.metrics.Store(newMetrics())
+
+	locationLinks, err := s.discoverLocations(ctx, baseURL)
+	if err != nil {
+		return err
+	}
+	return s.crawlLocations(ctx, start, locationLinks, out)
+}
+
+// CollectURLs runs discovery from baseURL, with the same location, card,
+// pagination and dedup logic as ScrapeStream, but stops before extracting
+// any listing. It returns the canonical listing URLs, after robots.txt, the
+// URL filter and MaxProperties are applied. Failed location pages are
+// reported as a *domain.ScrapeError alongside the URLs that were found.
+func (s *ChromedpScraper) CollectURLs(ctx context.Context, baseURL string) ([]string, error) {
+	start := time.Now()
+	s.log.Info("url collection started", "url", baseURL)
+	s.metrics.Store(newMetrics())
+
+	locationLinks, err := s.discoverLocations(ctx, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range locationLinks {
+		s.progress.OnLocationFound(l.URL)
+	}
+
+	propertyURLs, cardFailures := s.extractAllCardLinksConcurrent(locationLinks)
+	propertyURLs = s.prepareURLs(ctx, s.dedupe(propertyURLs))
+	for i, u := range propertyURLs {
+		propertyURLs[i] = s.site.CanonicalURL(u)
+	}
+
+	s.log.Info("url collection finished",
+		"locations", len(locationLinks),
+		"urls", len(propertyURLs),
+		"failed", len(cardFailures),
+		"duration", time.Since(start))
+
+	if len(cardFailures) > 0 {
+		return propertyURLs, &domain.ScrapeError{Failures: cardFailures, Attempted: len(locationLinks)}
+	}
+	return propertyURLs, nil
+}
+
+// discoverLocations collects the location pages to crawl from the homepage at
+// baseURL, falling back to SeedSearchURLs when the homepage yields none.
+func (s *ChromedpScraper) discoverLocations(ctx context.Context, baseURL string) ([]LocationLink, error) {
+	if !s.allowedByRobots(ctx, s.localURL(baseURL)) {
+		return nil, fmt.Errorf("scrape %s: %w", baseURL, ErrDisallowedByRobots)
+	}
 
 	// Step 1: extract location links
 	locationLinks, err := s.extractLocationLinks(baseURL)
+	if err == nil && len(locationLinks) == 0 {
+		err = fmt.Errorf("%s: %w", baseURL, ErrNoLocationLinks)
+	}
 	if err != nil {
-		return nil, err
+		seeds := s.cfg.Scraper.SeedSearchURLs
+		if len(seeds) == 0 || s.parent.Err() != nil {
+			return nil, err
+		}
+		s.log.Warn("homepage discovery failed; crawling seed search urls", "seeds", len(seeds), "error", err)
+		locationLinks = make([]LocationLink, len(seeds))
+		for i, u := range seeds {
+			locationLinks[i] = LocationLink{URL: u}
+		}
+	}
+	locationLinks = slices.DeleteFunc(locationLinks, func(l LocationLink) bool {
+		return !s.allowedByRobots(ctx, s.localURL(l.URL))
+	})
+	s.log.Info("location urls found", "count", len(locationLinks))
+	return locationLinks, nil
+}
+
+// ScrapeSearch scrapes a single search/location results page (e.g. "Homes in
+// Paris"), skipping homepage discovery. If searchURL is actually a listing it
+// is scraped on its own, as with ScrapeURLs.
+func (s *ChromedpScraper) ScrapeSearch(ctx context.Context, searchURL string) ([]models.Property, error) {
+	if s.site.IsListingURL(searchURL) {
+		s.log.Info("search url is a listing; scraping it directly", "url", searchURL)
+		return s.ScrapeURLs(ctx, []string{searchURL})
+	}
+
+	return collect(func(out chan<- models.Property) error {
+		start := time.Now()
+		s.log.Info("scrape started", "search_url", searchURL)
+		s.metrics.Store(newMetrics())
+
+		if !s.allowedByRobots(ctx, s.localURL(searchURL)) {
+			return fmt.Errorf("scrape %s: %w", searchURL, ErrDisallowedByRobots)
+		}
+		return s.crawlLocations(ctx, start, []LocationLink{{URL: searchURL}}, out)
+	})
+}
+
+// crawlLocations collects listing links from every location page and extracts
+// them through the worker pool, sending each property to out.
+func (s *ChromedpScraper) crawlLocations(ctx context.Context, start time.Time, locationLinks []LocationLink, out chan<- models.Property) error {
+	for _, l := range locationLinks {
+		s.progress.OnLocationFound(l.URL)
 	}
-	log.Printf("scrape: found %d location urls", len(locationLinks))
-	log.Printf("scrape: scraping %d location urls to get properties...", len(locationLinks))
 
 	// Step 2: extract all card links concurrently
-	propertyURLs := s.extractAllCardLinksConcurrent(locationLinks)
-	log.Printf("scrape: collected %d property URLs", len(propertyURLs))
+	propertyURLs, cardFailures := s.extractAllCardLinksConcurrent(locationLinks)
+	propertyURLs = s.dedupe(propertyURLs)
+	s.log.Info("property urls collected", "count", len(propertyURLs))
+	propertyURLs = s.prepareURLs(ctx, propertyURLs)
 
 	// Step 3: extract products concurrently via worker pool
-	property := s.extractPropertiesWorkerPool(propertyURLs, s.cfg.Concurrency.ProductWorkers)
+	fetched, propertyFailures := s.extractPropertiesWorkerPool(ctx, propertyURLs, s.cfg.Concurrency.ProductWorkers, out)
 
 	duration := time.Since(start)
-	failed := len(propertyURLs) - len(property)
-	if failed < 0 {
-		failed = 0
+	s.log.Info("scrape finished",
+		"locations", len(locationLinks),
+		"urls", len(propertyURLs),
+		"fetched", fetched,
+		"failed", len(propertyFailures),
+		"duration", duration)
+
+	failures := append(cardFailures, propertyFailures...)
+	if len(failures) > 0 {
+		return &domain.ScrapeError{Failures: failures, Attempted: len(locationLinks) + len(propertyURLs)}
 	}
 
-	log.Printf("scrape: finished — locations=%d urls=%d fetched=%d failed=%d duration=%s",
-		len(locationLinks), len(propertyURLs), len(property), failed, duration)
-
-	return property, nil
+	return nil
 }
 
+// cardLinkConcurrency is how many location pages are crawled for card links at once.
+const cardLinkConcurrency = 3
+
 // CARD LINKS CONCURRENT
-func (s *ChromedpScraper) extractAllCardLinksConcurrent(locations []LocationLink) []string {
+func (s *ChromedpScraper) extractAllCardLinksConcurrent(locations []LocationLink) ([]string, []*domain.URLError) {
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	sem := make(chan struct{}, 3)
+	sem := make(chan struct{}, cardLinkConcurrency)
 
 	var allLinks []string
+	var failures []*domain.URLError
 
 	for _, loc := range locations {
 
@@ -192,34 +674,109 @@ func (s *ChromedpScraper) extractAllCardLinksConcurrent(locations []LocationLink
 			defer wg.Done()
 
 			sem <- struct{}{}
-			links := s.extractCardLinks(locationURL)
+			links, err := s.extractCardLinks(locationURL)
 			<-sem
+			s.metrics.Load().recordLocation(err)
 
 			mu.Lock()
 			allLinks = append(allLinks, links...)
+			if err != nil {
+				failures = append(failures, &domain.URLError{Stage: "cards", URL: locationURL, Err: err})
+			}
 			mu.Unlock()
+			if err != nil {
+				s.progress.OnError(locationURL, err)
+			}
 
 		}(loc.URL)
 	}
 
 	wg.Wait()
 
-	return allLinks
+	return allLinks, failures
 }
 
 
+// dedupe removes URLs pointing at the same listing, keeping the first
+// occurrence of each so its search parameters are used for navigation.
+func (s *ChromedpScraper) dedupe(urls []string) []string {
+	seen := make(map[string]bool, len(urls))
+	out := make([]string, 0, len(urls))
+	for _, u := range urls {
+		key := s.site.CanonicalURL(u)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, u)
+	}
+	return out
+}
+
 // WORKER POOL PROPERTY EXTRACTION
+// Each extracted property is sent to out as soon as it is ready.
+// Returns the number of properties fetched and the per-URL failures.
+// A fatal failure (see isFatal) cancels the remaining work; URLs left
+// unscraped are reported as failed with that error, so every URL ends up
+// either sent to out or in the failures. A property extracted after the
+// caller's ctx is done, when out may no longer be read, is reported as
+// failed too.
 func (s *ChromedpScraper) extractPropertiesWorkerPool(
+	ctx context.Context,
 	cardLinks []string,
 	workerCount int,
-) []models.Property {
+	out chan<- models.Property,
+) (int, []*domain.URLError) {
 
 	jobs := make(chan string, len(cardLinks))
-	results := make(chan models.Property, len(cardLinks))
+
+	// cancelled with the cause when a worker hits a fatal error, so the rest stop promptly
+	caller := ctx
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
 
 	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failures []*domain.URLError
+	fail := func(urlErr *domain.URLError) {
+		mu.Lock()
+		failures = append(failures, urlErr)
+		mu.Unlock()
+	}
+	// deliver sends p to out, giving up only once the caller's ctx is done:
+	// a fatal stop of the pool doesn't stop the caller reading out. A reader
+	// that is still there wins over a done ctx.
+	deliver := func(p models.Property) bool {
+		select {
+		case out <- p:
+			return true
+		default:
+		}
+		select {
+		case out <- p:
+			return true
+		case <-caller.Done():
+			return false
+		}
+	}
+	// skip reports a URL the pool stopped before attempting. Like the rest of
+	// the crawl, URLs left by a cancelled or expired run are not reported.
+	skip := func(url string) {
+		if cause := context.Cause(ctx); !errors.Is(cause, context.Canceled) && !errors.Is(cause, context.DeadlineExceeded) {
+			fail(&domain.URLError{Stage: "property", URL: url, Err: fmt.Errorf("not attempted: %w", cause)})
+		}
+	}
 
-	log.Printf("workerpool: starting %d workers for %d jobs", workerCount, len(cardLinks))
+	s.log.Info("worker pool starting", "workers", workerCount, "jobs", len(cardLinks))
+	for _, link := range cardLinks {
+		s.progress.OnURLDiscovered(link)
+	}
+
+	// one tab per worker, reused across pages
+	tabs := scraper.NewTabPool(s.browser, workerCount, s.cfg.Browser.TabMaxUses,
+		scraper.BlockResources(s.cfg.Browser.BlockResources), s.maskFingerprint())
+	defer tabs.Close()
+	recycler := s.newBrowserRecycler()
 
 	var fetchedCount int32
 	for i := 0; i < workerCount; i++ {
@@ -227,34 +784,52 @@ func (s *ChromedpScraper) extractPropertiesWorkerPool(
 		go func(id int) {
 			defer wg.Done()
 			for url := range jobs {
-				property, err := s.extractProperty(url)
+				// drain the queue so every URL left is reported
+				if ctx.Err() != nil {
+					skip(url)
+					continue
+				}
+				started := time.Now()
+				telemetry.ActiveWorkers.Inc()
+				recycler.begin()
+				property, err := s.extract(ctx, tabs, url)
+				recycler.end()
+				telemetry.ActiveWorkers.Dec()
+				elapsed := time.Since(started)
+				s.metrics.Load().recordProperty(elapsed, err)
+				telemetry.ExtractionDuration.Observe(elapsed.Seconds())
 				if err != nil {
-					log.Printf("[property] worker %d: failed %s: %v", id, url, err)
+					if s.isFatal(err) {
+						s.log.Error("fatal error; stopping worker pool", "worker_id", id, "url", url, "error", err)
+						stop(err)
+					}
+					telemetry.PropertiesFailed.Inc()
+					s.log.Warn("property failed", "worker_id", id, "url", url, "error", err)
+					fail(&domain.URLError{Stage: "property", URL: url, Err: err, Attempts: attemptsOf(err)})
+					s.progress.OnError(url, err)
+					continue
+				}
+				telemetry.PropertiesScraped.Inc()
+				if !deliver(property) {
+					fail(&domain.URLError{Stage: "property", URL: url, Err: fmt.Errorf("not delivered: %w", caller.Err())})
 					continue
 				}
 				n := atomic.AddInt32(&fetchedCount, 1)
-				log.Printf("[property] #%d fetched: %s", n, property.Title)
-				results <- property
+				s.log.Info("property fetched", "worker_id", id, "n", n, "title", property.Title)
+				s.progress.OnPropertyScraped(int(n), len(cardLinks))
 			}
 		}(i)
 	}
 
-	// send jobs
+	// send jobs (buffered to len(cardLinks), so this never blocks)
 	for _, link := range cardLinks {
 		jobs <- link
 	}
-
 	close(jobs)
 
 	wg.Wait()
-	close(results)
-
-	var properties []models.Property
-	for p := range results {
-		properties = append(properties, p)
-	}
 
-	return properties
+	return int(atomic.LoadInt32(&fetchedCount)), failures
 }
 
 
@@ -262,18 +837,34 @@ type LocationLink struct {
 	URL  string `json:"url"`
 }
 
+// extractLocationLinks collects location links from the homepage. The tab has
+// its own LocationPageTimeout budget so the heavy homepage can't eat into the
+// per-product timeouts.
 func (s *ChromedpScraper) extractLocationLinks(url string) ([]LocationLink, error) {
-	tab, cancel := scraper.NewTab(s.allocatorCtx)
+	tab, cancel := scraper.NewTabWithTimeout(s.browser(), s.cfg.Timing.LocationPageTimeout)
 	defer cancel()
 
+	// registered once, outside the retries, so attempts don't stack copies
+	if err := chromedp.Run(tab, s.maskFingerprint()); err != nil {
+		return nil, fmt.Errorf("extractLocationLinks %s: %w", url, classify(ErrNavigation, err))
+	}
+
 	var rawJSON string
 
 	err := s.runWithRetry(tab,
-		chromedp.Navigate(url),
-		chromedp.WaitVisible(`h2`, chromedp.ByQuery),
-		scraper.ScrollToBottom(&s.cfg.Timing, s.cfg.Scraper.ScrollStep),
-		chromedp.Sleep(s.cfg.Timing.AfterScrollWait),
-		chromedp.Evaluate(locationLinksJS, &rawJSON),
+		tagged(ErrNavigation,
+			s.setTabUserAgent(),
+			s.setTabHeaders(),
+			s.restoreCookies(),
+			scraper.CheckRateLimit(chromedp.Navigate(s.localURL(url))),
+			chromedp.WaitVisible(`h2`, chromedp.ByQuery),
+			s.scrollPage(),
+			chromedp.Sleep(s.cfg.Timing.AfterScrollWait),
+		),
+		tagged(ErrExtraction,
+			chromedp.Evaluate(s.site.LocationLinksJS(s.fieldSelectors(config.SelectorLocationLinks)), &rawJSON),
+		),
+		s.captureCookies(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("extractLocationLinks %s: %w", url, err)
@@ -281,7 +872,7 @@ func (s *ChromedpScraper) extractLocationLinks(url string) ([]LocationLink, erro
 
 	var links []LocationLink
 	if err := json.Unmarshal([]byte(rawJSON), &links); err != nil {
-		return nil, fmt.Errorf("extractLocationLinks parse JSON: %w", err)
+		return nil, fmt.Errorf("extractLocationLinks parse JSON: %w", classify(ErrExtraction, err))
 	}
 
 	return links, nil
@@ -290,43 +881,124 @@ func (s *ChromedpScraper) extractLocationLinks(url string) ([]LocationLink, erro
 // extractCardLinks opens a location search page and collects listing hrefs.
 // It scrolls to load all cards, then checks for a second page via pagination.
 // A single tab is reused for both pages to avoid allocator pressure.
-func (s *ChromedpScraper) extractCardLinks(locationURL string) []string {
-	tab, cancel := scraper.NewTab(s.allocatorCtx)
+// If page 2 fails, the page 1 links are still returned alongside the error.
+// Each page has its own CardPageTimeout; a page 1 that times out yields no
+// next-page link, so page 2 is then not attempted. Once MaxPerLocation
+// distinct listings are collected, pagination stops.
+func (s *ChromedpScraper) extractCardLinks(locationURL string) ([]string, error) {
+	tab, cancel := scraper.NewTab(s.browser())
 	defer cancel()
 
+	// one user agent for both pages, like a real visitor paging through results
+	if err := chromedp.Run(tab,
+		scraper.BlockResources(s.cfg.Browser.BlockResources), s.maskFingerprint(),
+		s.setTabUserAgent(), s.setTabHeaders(), s.restoreCookies()); err != nil {
+		return nil, classify(ErrNavigation, err)
+	}
+
 	// Page 1
-	page1 := s.scrapeCardPage(tab, locationURL)
+	page1, err := s.scrapeCardPage(tab, locationURL)
+	if err != nil {
+		return nil, err
+	}
+	page1, full := s.capLocation(page1)
+	if full {
+		s.log.Info("location cap reached; not paginating", "url", locationURL, "max_per_location", s.cfg.Scraper.MaxPerLocation)
+		return page1, nil
+	}
+
+	if s.cfg.Scraper.StopAtKnownPage && s.allKnown(tab, page1) {
+		s.log.Info("every listing on page already stored; not paginating", "url", locationURL, "listings", len(page1))
+		return page1, nil
+	}
 
 	// Check for next page while still on page 1
 	nextURL := s.findNextPageURL(tab)
-	if nextURL == "" {
-		return page1
+	if nextURL == "" || !s.allowedByRobots(s.parent, s.localURL(nextURL)) {
+		return page1, nil
 	}
 
 	// Page 2 (reuse same tab)
-	page2 := s.scrapeCardPage(tab, nextURL)
-	return append(page1, page2...)
+	page2, err := s.scrapeCardPage(tab, nextURL)
+	links, _ := s.capLocation(append(page1, page2...))
+	return links, err
+}
+
+// capLocation dedupes one location's links and cuts them to MaxPerLocation,
+// reporting whether the cap has been reached.
+func (s *ChromedpScraper) capLocation(links []string) ([]string, bool) {
+	limit := s.cfg.Scraper.MaxPerLocation
+	if limit <= 0 {
+		return links, false
+	}
+	links = s.dedupe(links)
+	if len(links) >= limit {
+		return links[:limit], true
+	}
+	return links, false
 }
 
-// scrapeCardPage navigates to url in the given tab, scrolls, and returns card hrefs.
-func (s *ChromedpScraper) scrapeCardPage(ctx context.Context, url string) []string {
-	s.applyRateLimit()
+// allKnown reports whether every link on a results page is already stored,
+// by their canonical URLs. Without a lookup, or if it fails, nothing is known.
+func (s *ChromedpScraper) allKnown(ctx context.Context, links []string) bool {
+	if s.knownURLs == nil || len(links) == 0 {
+		return false
+	}
+	canonical := make([]string, len(links))
+	for i, u := range links {
+		canonical[i] = s.site.CanonicalURL(u)
+	}
+	known, err := s.knownURLs(ctx, canonical)
+	if err != nil {
+		s.log.Warn("stored listing lookup failed; paginating", "error", err)
+		return false
+	}
+	for _, u := range canonical {
+		if !known[u] {
+			return false
+		}
+	}
+	return true
+}
+
+// scrapeCardPage navigates to url in the given tab, scrolls, and returns card
+// hrefs. The page is bounded by CardPageTimeout, counted after the rate limit
+// wait; a timeout only ends this page, not the tab.
+func (s *ChromedpScraper) scrapeCardPage(tab context.Context, url string) ([]string, error) {
+	if err := s.applyRateLimit(tab, url); err != nil {
+		return nil, fmt.Errorf("scrapeCardPage %s: %w", url, err)
+	}
 	s.randomDelay()
 
+	ctx := tab
+	if timeout := s.cfg.Timing.CardPageTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(tab, timeout)
+		defer cancel()
+	}
+
 	var links []string
 
 	err := s.runWithRetry(ctx,
-		chromedp.Navigate(url),
-		chromedp.Sleep(s.cfg.Timing.PageLoadWait),
-		scraper.ScrollToBottom(&s.cfg.Timing, s.cfg.Scraper.ScrollStep),
-		chromedp.Sleep(s.cfg.Timing.AfterScrollWait),
-		chromedp.Evaluate(cardLinksJS(s.cfg.Scraper.CardsPage1), &links),
+		tagged(ErrNavigation,
+			scraper.NavigateAndSettle(s.localURL(url), &s.cfg.Timing, s.cfg.Timing.PageLoadWait),
+			detectCaptcha(s.site.CaptchaJS()),
+			s.scrollPage(),
+			chromedp.Sleep(s.cfg.Timing.AfterScrollWait),
+		),
+		tagged(ErrExtraction,
+			chromedp.Evaluate(s.site.CardLinksJS(s.cfg.Scraper.CardsPage1), &links),
+		),
+		s.captureCookies(),
 	)
+	s.reportOutcome(url, err)
 	if err != nil {
-		log.Printf("[cards] scrapeCardPage error %s: %v", url, err)
+		s.log.Warn("card page failed", "url", url, "error", err)
+		s.maybeDumpHTML(tab, url, true)
+		return nil, fmt.Errorf("scrapeCardPage %s: %w", url, err)
 	}
 
-	return links
+	return links, nil
 }
 
 // findNextPageURL reads the current tab DOM and returns the "Next" page href,
@@ -335,72 +1007,153 @@ func (s *ChromedpScraper) findNextPageURL(ctx context.Context) string {
 	var nextURL string
 
 	_ = chromedp.Run(ctx,
-		chromedp.Evaluate(nextPageJS, &nextURL),
+		chromedp.Evaluate(s.site.NextPageJS(), &nextURL),
 	)
 
 	return nextURL
 }
 
-func (s *ChromedpScraper) extractProperty(url string) (models.Property, error) {
-	s.applyRateLimit()
+func (s *ChromedpScraper) extractProperty(ctx context.Context, tabs *scraper.TabPool, url string) (models.Property, error) {
+	roomID, err := s.site.ListingID(url)
+	if err != nil {
+		return models.Property{}, err
+	}
+	if err := s.applyRateLimit(ctx, url); err != nil {
+		return models.Property{}, err
+	}
 	s.randomDelay()
 
-	// Create the browser context FIRST, then wrap it with timeout
-    // so the timeout applies to the tab's operations, not the allocator lifetime
-    browserCtx, browserCancel := chromedp.NewContext(s.allocatorCtx)
-    defer browserCancel()
+	// Check out a pooled tab, then wrap it with timeout
+    // so the timeout applies to this page's operations, not the tab's lifetime
+    tab, err := tabs.Get(ctx)
+    if err != nil && ctx.Err() == nil {
+        // Chrome may have crashed; relaunch it once before declaring it dead
+        s.ensureBrowser()
+        if tab, err = tabs.Get(ctx); err != nil && ctx.Err() == nil {
+            err = fmt.Errorf("%w: %w", ErrBrowserDead, err)
+        }
+    }
+    if err != nil {
+        return models.Property{}, classify(ErrNavigation, err)
+    }
+    browserCtx := tab.Context()
 
     tabCtx, cancel := context.WithTimeout(browserCtx, s.cfg.Timing.ProductTimeout)
     defer cancel()
 
-    var title, priceText, location, ratingText, description, daysText string
-
-
-    err := s.runWithRetry(tabCtx,
-        chromedp.Navigate(url),
-        chromedp.WaitVisible(`div[data-plugin-in-point-id="TITLE_DEFAULT"]`, chromedp.ByQuery),
-        chromedp.Evaluate(titleJS, &title),
-		chromedp.WaitVisible(`div[data-testid="book-it-default"]`, chromedp.ByQuery),
-        chromedp.Evaluate(priceJS, &priceText),
-		chromedp.Evaluate(nightsJS, &daysText),
-        chromedp.Evaluate(ratingJS, &ratingText),
-        chromedp.WaitVisible(`div[data-section-id="LOCATION_DEFAULT"]`, chromedp.ByQuery),
-        chromedp.Evaluate(locationJS, &location),
-        chromedp.Evaluate(`
-            (() => {
-                const btn = document.querySelector('button[aria-label="Show more about this place"]');
-                if (btn) btn.click();
-            })()
-        `, nil),
-        chromedp.Evaluate(descriptionJS, &description),
+    page := s.site.ProductPage()
+    var title, priceText, priceLabel, location, ratingText, description, daysText, typeText, coordsText string
+
+    // each field is read on its own, so one broken extractor only loses that field
+    navigated := false
+    err = s.runWithRetry(tabCtx,
+        // reset on every attempt, so a retry that fails to load isn't taken as navigated
+        chromedp.ActionFunc(func(context.Context) error {
+            navigated = false
+            return nil
+        }),
+        tagged(ErrNavigation,
+            s.setTabUserAgent(),
+            s.setTabHeaders(),
+            s.restoreCookies(),
+            s.timedPageLoad(scraper.NavigateAndSettle(s.localURL(url), &s.cfg.Timing, s.cfg.Timing.ProductPageWait)),
+            detectCaptcha(s.site.CaptchaJS()),
+        ),
+        chromedp.ActionFunc(func(context.Context) error {
+            navigated = true
+            return nil
+        }),
+        tagged(ErrExtraction,
+            // sections missing on some listing types are skipped after a short wait;
+            // retryEmptyFields then decides whether what was read is enough.
+            // Each field is timed together with the waits it needs.
+            s.timedField(config.SelectorTitle, &title,
+                scraper.WaitVisibleUpTo(page.TitleSection, s.cfg.Timing.ElementWaitTimeout),
+                utils.SafeEvaluate(s.fieldJS(config.SelectorTitle), &title)),
+            s.timedField(config.SelectorPrice, &priceText,
+                scraper.WaitVisibleUpTo(page.BookingSection, s.cfg.Timing.ElementWaitTimeout),
+                scraper.WaitVisibleUpTo(s.fieldSelector(config.SelectorPrice), s.cfg.Timing.SectionWaitTimeout),
+                utils.SafeEvaluate(s.fieldJS(config.SelectorPrice), &priceText)),
+            s.timedField("price label", &priceLabel,
+                utils.SafeEvaluate(priceLabelJS(s.fieldSelectors(config.SelectorPrice)), &priceLabel)),
+            s.timedField(config.SelectorNights, &daysText,
+                utils.SafeEvaluate(s.fieldJS(config.SelectorNights), &daysText)),
+            s.timedField(config.SelectorRating, &ratingText,
+                scraper.WaitVisibleUpTo(s.fieldSelector(config.SelectorRating), s.cfg.Timing.SectionWaitTimeout),
+                utils.SafeEvaluate(s.fieldJS(config.SelectorRating), &ratingText)),
+            s.timedField("property type", &typeText,
+                utils.SafeEvaluate(page.PropertyTypeJS, &typeText)),
+            s.timedField(config.SelectorLocation, &location,
+                scraper.WaitVisibleUpTo(page.LocationSection, s.cfg.Timing.ElementWaitTimeout),
+                utils.SafeEvaluate(s.fieldJS(config.SelectorLocation), &location)),
+            s.timedField("coordinates", &coordsText,
+                utils.SafeEvaluate(page.CoordinatesJS, &coordsText)),
+            s.timedField("description", &description,
+                s.expandSection(page.ExpandDescriptionJS, page.DescriptionModal),
+                utils.SafeEvaluate(page.DescriptionJS, &description)),
+        ),
+        s.captureCookies(),
     )
+	// the page loaded, so keep what was read before extraction was cut short;
+	// only missing required fields fail the listing from here on
+	if err != nil && navigated && !errors.Is(err, context.Canceled) {
+		s.log.Warn("extraction incomplete; keeping fields read so far", "url", url, "error", err)
+		err = nil
+	}
+	if err == nil {
+		err = s.retryEmptyFields(tabCtx, url, map[string]*string{
+			config.SelectorTitle:    &title,
+			config.SelectorPrice:    &priceText,
+			config.SelectorNights:   &daysText,
+			config.SelectorRating:   &ratingText,
+			config.SelectorLocation: &location,
+		})
+	}
+	s.reportOutcome(url, err)
+	s.maybeDumpHTML(browserCtx, url, err != nil)
+	var reviews []string
+	var datePrices []models.DatePrice
+	if err == nil {
+		// both run after the main fields; date prices navigate away from the page
+		reviews = s.scrapeReviews(browserCtx, url)
+		datePrices = s.scrapeDatePrices(ctx, browserCtx, url)
+	}
+	tabs.Put(tab, err == nil)
 	if err != nil {
 		return models.Property{}, err
 	}
 
-	// if daysText is "", default to 1 night
-	// if daytext is "for X nights", extract X and use it calculate per night price
-	nights := 1
-	if daysText != "" {
-		nights = utils.ParseNights(daysText)
-	}
+	// a price labelled as the total for X nights is turned into a nightly
+	// price; anything else is kept as shown, with PriceType saying what it is
+	price, priceType := utils.NightlyPrice(priceText, priceLabel, daysText)
 
-	price := utils.ParsePrice(priceText)
-	if nights > 1 && price > 0 {
-		price = price / float32(nights)
+	rating, err := utils.ParseRating(ratingText)
+	if err != nil {
+		s.log.Debug("rating not parsed", "url", url, "error", err)
 	}
 
+	lat, lng, _ := utils.ParseCoordinates(coordsText)
+
 	property := models.Property{
-		Platform: "Airbnb",
-		Title:    title,
+		RoomID:   roomID,
+		Platform: s.site.Platform(),
+		Title:    utils.CleanText(title),
 		Price:    price,
-		Location: location,
-		URL:      url,
-		Rating:   utils.ParseRating(ratingText),
-		Description:  description,
+		PriceType: priceType,
+		Nights:   utils.ParseNights(daysText),
+		Location: utils.CleanText(location),
+		URL:      s.site.CanonicalURL(url),
+		Rating:   rating,
+		Description:  utils.CleanText(description),
+		PropertyType: utils.NormalizePropertyType(typeText),
+		Latitude:     lat,
+		Longitude:    lng,
+		ScrapedAt:    time.Now().UTC(),
+		Reviews:      reviews,
+		DatePrices:   datePrices,
 	}
 
-	log.Printf("[property] fetched: %s", property.URL)
+	s.log.Debug("property extracted", "url", property.URL)
 	return property, nil
 }
 
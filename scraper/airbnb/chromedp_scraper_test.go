@@ -0,0 +1,306 @@
+package airbnb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"scraping-airbnb/config"
+	"scraping-airbnb/models"
+	"scraping-airbnb/scraper"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+func newTestScraper(t *testing.T, cfg *config.Config) *ChromedpScraper {
+	t.Helper()
+	s := NewChromedpScraper(context.Background(), nil, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestStealthChoicesAreDeterministicForSeed(t *testing.T) {
+	cfg := config.Default()
+	cfg.Stealth.RandomDelayEnabled = true
+	cfg.Stealth.RandomDelayMin = 100 * time.Millisecond
+	cfg.Stealth.RandomDelayMax = 2 * time.Second
+	cfg.Stealth.RandomUserAgentEnabled = true
+	cfg.Stealth.Seed = 42
+
+	sequence := func(s *ChromedpScraper) []any {
+		var seq []any
+		for range 10 {
+			seq = append(seq, s.nextDelay(), s.getRandomUserAgent())
+		}
+		return seq
+	}
+
+	a, b := newTestScraper(t, cfg), newTestScraper(t, cfg)
+	first, second := sequence(a), sequence(b)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("choice %d differs for the same seed: %v vs %v", i, first[i], second[i])
+		}
+	}
+
+	// reseeding replays the sequence
+	a.SetSeed(42)
+	replay := sequence(a)
+	for i := range first {
+		if first[i] != replay[i] {
+			t.Fatalf("choice %d differs after SetSeed: %v vs %v", i, first[i], replay[i])
+		}
+	}
+
+	for i := 0; i < len(first); i += 2 {
+		d := first[i].(time.Duration)
+		if d < cfg.Stealth.RandomDelayMin || d >= cfg.Stealth.RandomDelayMax {
+			t.Errorf("delay %v outside [%v, %v)", d, cfg.Stealth.RandomDelayMin, cfg.Stealth.RandomDelayMax)
+		}
+	}
+}
+
+func TestUserAgentPoolFollowsDevice(t *testing.T) {
+	for _, tc := range []struct {
+		device          string
+		desktop, mobile bool
+	}{
+		{config.DeviceDesktop, true, false},
+		{config.DeviceMobile, false, true},
+		{config.DeviceMixed, true, true},
+	} {
+		var desktop, mobile bool
+		for _, ua := range userAgentPool(tc.device) {
+			if scraper.IsMobileUserAgent(ua) {
+				mobile = true
+			} else {
+				desktop = true
+			}
+		}
+		if desktop != tc.desktop || mobile != tc.mobile {
+			t.Errorf("%s pool: desktop=%v mobile=%v, want desktop=%v mobile=%v",
+				tc.device, desktop, mobile, tc.desktop, tc.mobile)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Stealth.RandomUserAgentEnabled = false
+	cfg.Stealth.Device = config.DeviceMobile
+	if ua := newTestScraper(t, cfg).getRandomUserAgent(); !scraper.IsMobileUserAgent(ua) {
+		t.Errorf("mobile profile without rotation uses %q", ua)
+	}
+}
+
+func TestSetTabUserAgentPicksPerTab(t *testing.T) {
+	tabUA := func(s *ChromedpScraper) string {
+		t.Helper()
+		override, ok := s.setTabUserAgent().(chromedp.Tasks)[0].(*emulation.SetUserAgentOverrideParams)
+		if !ok {
+			t.Fatal("setTabUserAgent does not start with a user agent override")
+		}
+		return override.UserAgent
+	}
+
+	cfg := config.Default()
+	cfg.Stealth.RandomUserAgentEnabled = true
+	cfg.Stealth.Seed = 7
+	s := newTestScraper(t, cfg)
+	seen := make(map[string]bool)
+	for range 20 {
+		ua := tabUA(s)
+		if !slices.Contains(s.userAgents, ua) {
+			t.Fatalf("tab user agent %q is not in the pool", ua)
+		}
+		seen[ua] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("20 tabs all got user agent %v, want a fresh pick per tab", seen)
+	}
+
+	cfg = config.Default()
+	cfg.Stealth.RandomUserAgentEnabled = false
+	if ua := tabUA(newTestScraper(t, cfg)); ua != cfg.Browser.UserAgent {
+		t.Errorf("without rotation the tab uses %q, want the browser's %q", ua, cfg.Browser.UserAgent)
+	}
+}
+
+func TestAllKnown(t *testing.T) {
+	s := newTestScraper(t, config.Default())
+	page := []string{"https://www.airbnb.com/rooms/1?adults=2", "https://www.airbnb.com/rooms/2?adults=2"}
+	if s.allKnown(context.Background(), page) {
+		t.Error("allKnown() = true without a lookup")
+	}
+
+	stored := map[string]bool{"https://www.airbnb.com/rooms/1": true}
+	s.SetKnownURLs(func(_ context.Context, urls []string) (map[string]bool, error) {
+		known := make(map[string]bool)
+		for _, u := range urls {
+			known[u] = stored[u]
+		}
+		return known, nil
+	})
+	// a filter adding other listings, as refreshStale does, must not matter
+	s.SetURLFilter(func(_ context.Context, urls []string) []string {
+		return append(urls, "https://www.airbnb.com/rooms/9")
+	})
+	if s.allKnown(context.Background(), page) {
+		t.Error("allKnown() = true with an unstored listing on the page")
+	}
+	stored["https://www.airbnb.com/rooms/2"] = true
+	if !s.allKnown(context.Background(), page) {
+		t.Error("allKnown() = false with every listing stored")
+	}
+}
+
+func TestNormalDelaysStayInRange(t *testing.T) {
+	cfg := config.Default()
+	cfg.Stealth.RandomDelayEnabled = true
+	cfg.Stealth.RandomDelayMin = time.Second
+	cfg.Stealth.RandomDelayMax = 3 * time.Second
+	cfg.Stealth.DelayDistribution = config.DelayNormal
+	cfg.Stealth.Seed = 7
+	s := newTestScraper(t, cfg)
+
+	var near int
+	for range 1000 {
+		d := s.nextDelay()
+		if d < cfg.Stealth.RandomDelayMin || d > cfg.Stealth.RandomDelayMax {
+			t.Fatalf("nextDelay() = %s, outside [1s, 3s]", d)
+		}
+		if d > 1500*time.Millisecond && d < 2500*time.Millisecond {
+			near++
+		}
+	}
+	// ±1.5σ around the mean holds ~87% of a normal distribution, 50% of a uniform one
+	if near < 800 {
+		t.Errorf("%d of 1000 delays within 500ms of the mean, want clustering", near)
+	}
+}
+
+func TestCapLocation(t *testing.T) {
+	cfg := config.Default()
+	cfg.Scraper.MaxPerLocation = 2
+	s := newTestScraper(t, cfg)
+
+	links := []string{
+		"https://www.airbnb.com/rooms/1?check_in=2025-07-04",
+		"https://www.airbnb.com/rooms/1",
+		"https://www.airbnb.com/rooms/2",
+		"https://www.airbnb.com/rooms/3",
+	}
+	got, full := s.capLocation(links)
+	if !full || len(got) != 2 || got[1] != links[2] {
+		t.Errorf("capLocation() = %v, %v; want the first 2 distinct listings, full", got, full)
+	}
+
+	got, full = s.capLocation(links[:2])
+	if full || len(got) != 1 {
+		t.Errorf("capLocation() = %v, %v; want 1 listing, not full", got, full)
+	}
+}
+
+func TestReviewTexts(t *testing.T) {
+	if got := reviewTexts(false, []string{"Great stay"}, 5); got != nil {
+		t.Errorf("reviewTexts() without the modal = %v, want none", got)
+	}
+
+	got := reviewTexts(true, []string{"Great  stay\n", "Quiet", "Clean"}, 2)
+	if len(got) != 2 || got[0] != "Great stay" || got[1] != "Quiet" {
+		t.Errorf("reviewTexts() = %q, want the first 2 cleaned", got)
+	}
+}
+
+func TestPrepareURLsAsksRobotsAboutTheLocalizedURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /*currency=EUR\n")
+	}))
+	defer srv.Close()
+
+	cfg := config.Default()
+	cfg.Scraper.Currency = "EUR"
+	s := newTestScraper(t, cfg)
+	s.robots = scraper.NewRobotsChecker("test-agent")
+
+	if got := s.prepareURLs(context.Background(), []string{srv.URL + "/rooms/1"}); len(got) != 0 {
+		t.Errorf("prepareURLs() = %v, want the listing dropped: its localized URL is disallowed", got)
+	}
+
+	cfg.Scraper.Currency = "USD"
+	if got := s.prepareURLs(context.Background(), []string{srv.URL + "/rooms/1"}); len(got) != 1 {
+		t.Errorf("prepareURLs() = %v, want the listing kept", got)
+	}
+}
+
+func TestWorkerPoolAccountsForEveryURLAfterFatalError(t *testing.T) {
+	cfg := config.Default()
+	cfg.Stealth.RandomDelayEnabled = false
+	s := newTestScraper(t, cfg)
+
+	var cardLinks []string
+	for i := range 20 {
+		cardLinks = append(cardLinks, fmt.Sprintf("https://www.airbnb.com/rooms/%d", i))
+	}
+	s.extract = func(ctx context.Context, _ *scraper.TabPool, url string) (models.Property, error) {
+		if url == cardLinks[3] {
+			return models.Property{}, fmt.Errorf("open tab: %w", ErrBrowserDead)
+		}
+		// slow enough that the other workers are mid-page when the pool stops
+		select {
+		case <-time.After(5 * time.Millisecond):
+		case <-ctx.Done():
+			return models.Property{}, ctx.Err()
+		}
+		return models.Property{URL: url}, nil
+	}
+
+	out := make(chan models.Property)
+	var results []models.Property
+	done := make(chan struct{})
+	go func() {
+		for p := range out {
+			results = append(results, p)
+		}
+		close(done)
+	}()
+	fetched, failures := s.extractPropertiesWorkerPool(context.Background(), cardLinks, 4, out)
+	close(out)
+	<-done
+
+	if fetched != len(results) {
+		t.Errorf("fetched = %d, but %d properties were sent", fetched, len(results))
+	}
+	if got := len(results) + len(failures); got != len(cardLinks) {
+		t.Errorf("%d results + %d failures = %d, want all %d urls accounted for", len(results), len(failures), got, len(cardLinks))
+	}
+	seen := make(map[string]bool)
+	for _, p := range results {
+		seen[p.URL] = true
+	}
+	for _, f := range failures {
+		if seen[f.URL] {
+			t.Errorf("%s both sent and reported failed", f.URL)
+		}
+		seen[f.URL] = true
+	}
+	if len(seen) != len(cardLinks) {
+		t.Errorf("%d distinct urls accounted for, want %d", len(seen), len(cardLinks))
+	}
+}
+
+func TestPrepareURLsSkipsListingsWhenRobotsUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // robots.txt fetches now fail to connect
+
+	s := newTestScraper(t, config.Default())
+	s.robots = scraper.NewRobotsChecker("test-agent")
+
+	if got := s.prepareURLs(context.Background(), []string{srv.URL + "/rooms/1"}); len(got) != 0 {
+		t.Errorf("prepareURLs() = %v, want the listing skipped: robots.txt could not be fetched", got)
+	}
+}
@@ -0,0 +1,101 @@
+package airbnb
+
+import (
+	"context"
+	"maps"
+	"scraping-airbnb/scraper"
+	"slices"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+)
+
+// cookieKey identifies a cookie the way the browser does.
+type cookieKey struct{ name, domain, path string }
+
+// loadCookies seeds the jar from BrowserConfig.CookieJarPath. A missing or
+// unreadable jar is logged and the run simply starts without cookies.
+func (s *ChromedpScraper) loadCookies() {
+	path := s.cfg.Browser.CookieJarPath
+	if path == "" {
+		return
+	}
+	cookies, err := scraper.LoadCookies(path)
+	if err != nil {
+		s.log.Warn("ignoring cookie jar", "path", path, "error", err)
+		return
+	}
+
+	s.cookieMu.Lock()
+	defer s.cookieMu.Unlock()
+	s.cookies = make(map[cookieKey]*network.Cookie, len(cookies))
+	for _, c := range cookies {
+		s.cookies[cookieKey{c.Name, c.Domain, c.Path}] = c
+	}
+	s.log.Info("restored cookies", "path", path, "count", len(s.cookies))
+}
+
+// restoreCookies installs the jar into a fresh tab before it navigates.
+func (s *ChromedpScraper) restoreCookies() chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		s.cookieMu.Lock()
+		params := scraper.CookieParams(slices.Collect(maps.Values(s.cookies)), time.Now())
+		s.cookieMu.Unlock()
+
+		if len(params) == 0 {
+			return nil
+		}
+		return network.SetCookies(params).Do(ctx)
+	}
+}
+
+// captureCookies merges the tab's current cookies into the jar. Failures are
+// logged rather than returned so they never fail an otherwise good scrape.
+func (s *ChromedpScraper) captureCookies() chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		if s.cfg.Browser.CookieJarPath == "" {
+			return nil
+		}
+		get := storage.GetCookies()
+		// each tab has its own browser context; read that one, not the default
+		if c := chromedp.FromContext(ctx); c != nil && c.BrowserContextID != "" {
+			get = get.WithBrowserContextID(c.BrowserContextID)
+		}
+		cookies, err := get.Do(ctx)
+		if err != nil {
+			s.log.Debug("capture cookies failed", "error", err)
+			return nil
+		}
+
+		s.cookieMu.Lock()
+		defer s.cookieMu.Unlock()
+		if s.cookies == nil {
+			s.cookies = make(map[cookieKey]*network.Cookie, len(cookies))
+		}
+		for _, c := range cookies {
+			s.cookies[cookieKey{c.Name, c.Domain, c.Path}] = c
+		}
+		return nil
+	}
+}
+
+// SaveCookies writes the collected cookies to BrowserConfig.CookieJarPath so the
+// next run starts with a warmed-up session. It is a no-op when no path is set.
+func (s *ChromedpScraper) SaveCookies() error {
+	path := s.cfg.Browser.CookieJarPath
+	if path == "" {
+		return nil
+	}
+
+	s.cookieMu.Lock()
+	cookies := slices.Collect(maps.Values(s.cookies))
+	s.cookieMu.Unlock()
+
+	if err := scraper.SaveCookies(path, cookies); err != nil {
+		return err
+	}
+	s.log.Info("saved cookies", "path", path, "count", len(cookies))
+	return nil
+}
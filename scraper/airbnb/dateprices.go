@@ -0,0 +1,77 @@
+package airbnb
+
+import (
+	"context"
+	"fmt"
+	"scraping-airbnb/config"
+	"scraping-airbnb/models"
+	"scraping-airbnb/scraper"
+	"scraping-airbnb/utils"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// scrapeDatePrices re-opens url in tab once per ScraperConfig.DateRanges entry
+// and reads the nightly price for that stay. A range whose dated URL
+// robots.txt disallows is skipped; one that fails, for example because the
+// dates are booked, is logged and left out rather than failing the listing.
+func (s *ChromedpScraper) scrapeDatePrices(ctx, tab context.Context, url string) []models.DatePrice {
+	ranges := s.cfg.Scraper.DateRanges
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	prices := make([]models.DatePrice, 0, len(ranges))
+	for _, r := range ranges {
+		dated := s.localURL(s.site.DatedURL(url, r.CheckIn, r.CheckOut))
+		if !s.allowedByRobots(ctx, dated) {
+			continue
+		}
+		if err := s.applyRateLimit(ctx, url); err != nil {
+			break
+		}
+		price, err := s.stayPrice(tab, dated)
+		if err != nil {
+			s.log.Warn("dated price failed", "url", url,
+				"check_in", r.CheckIn.Format(time.DateOnly), "check_out", r.CheckOut.Format(time.DateOnly), "error", err)
+			continue
+		}
+		prices = append(prices, models.DatePrice{CheckIn: r.CheckIn, CheckOut: r.CheckOut, Price: price})
+	}
+	return prices
+}
+
+// stayPrice navigates tab to datedURL, a listing URL with the stay's dates
+// set, and returns the nightly price.
+func (s *ChromedpScraper) stayPrice(tab context.Context, datedURL string) (float32, error) {
+	ctx, cancel := context.WithTimeout(tab, s.cfg.Timing.ProductTimeout)
+	defer cancel()
+
+	page := s.site.ProductPage()
+	var priceText, priceLabel, nightsText string
+	err := chromedp.Run(ctx,
+		tagged(ErrNavigation,
+			scraper.NavigateAndSettle(datedURL, &s.cfg.Timing, s.cfg.Timing.ProductPageWait),
+			detectCaptcha(s.site.CaptchaJS()),
+		),
+		tagged(ErrExtraction,
+			scraper.WaitVisibleUpTo(page.BookingSection, s.cfg.Timing.ElementWaitTimeout),
+			scraper.WaitVisibleUpTo(s.fieldSelector(config.SelectorPrice), s.cfg.Timing.SectionWaitTimeout),
+			utils.SafeEvaluate(s.fieldJS(config.SelectorPrice), &priceText),
+			utils.SafeEvaluate(priceLabelJS(s.fieldSelectors(config.SelectorPrice)), &priceLabel),
+			utils.SafeEvaluate(s.fieldJS(config.SelectorNights), &nightsText),
+		),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// like the default price, one labelled as a "for N nights" total is
+	// turned into a nightly price
+	price, _ := utils.NightlyPrice(priceText, priceLabel, nightsText)
+	if price == 0 {
+		return 0, fmt.Errorf("no price shown, dates may be unavailable: %w", ErrExtraction)
+	}
+	return price, nil
+}
@@ -0,0 +1,46 @@
+package airbnb
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"scraping-airbnb/scraper"
+	"strings"
+	"time"
+)
+
+// debugDumpTimeout bounds how long an HTML dump may take, since the page's own
+// context has often already expired by the time we dump it.
+const debugDumpTimeout = 10 * time.Second
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// debugFileName derives a filesystem-safe .html name from a page URL.
+func debugFileName(url string) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return fmt.Sprintf("%s_%d.html", name, time.Now().UnixMilli())
+}
+
+// maybeDumpHTML writes the tab's DOM to the debug directory when HTML dumps are
+// enabled for this outcome. Failures to dump are logged, never returned.
+func (s *ChromedpScraper) maybeDumpHTML(tab context.Context, url string, failed bool) {
+	dbg := s.cfg.Debug
+	if !dbg.DumpHTMLAlways && !(failed && dbg.DumpHTMLOnFailure) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(tab, debugDumpTimeout)
+	defer cancel()
+
+	path := filepath.Join(dbg.Dir, debugFileName(url))
+	if err := scraper.DumpHTML(ctx, path); err != nil {
+		s.log.Warn("html dump failed", "url", url, "error", err)
+		return
+	}
+	s.log.Info("html dumped", "url", url, "path", path)
+}
@@ -0,0 +1,145 @@
+package airbnb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scraping-airbnb/internal/domain"
+	"scraping-airbnb/scraper"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ErrCaptchaDetected is returned when Airbnb serves a bot-check / "are you a human"
+// interstitial instead of the requested page. It is permanent unless
+// RetryConfig.RetryOnCaptcha is set.
+var ErrCaptchaDetected = fmt.Errorf("captcha challenge detected: %w", domain.ErrPermanent)
+
+// ErrDisallowedByRobots is returned when robots.txt forbids fetching a URL,
+// or can't be fetched to tell.
+var ErrDisallowedByRobots = fmt.Errorf("disallowed by robots.txt: %w", domain.ErrPermanent)
+
+// ErrRetryBudgetExhausted is returned when a retry is needed but the run has
+// already used up RetryConfig.GlobalBudget.
+var ErrRetryBudgetExhausted = fmt.Errorf("retry budget exhausted: %w", domain.ErrPermanent)
+
+// ErrNoRoomID is returned when a listing URL carries no numeric room id.
+var ErrNoRoomID = fmt.Errorf("no room id in url: %w", domain.ErrPermanent)
+
+// ErrBrowserDead is returned when Chrome cannot open a tab even after being
+// relaunched. Every other page would fail the same way, so it stops the run.
+var ErrBrowserDead = fmt.Errorf("browser unavailable: %w", domain.ErrPermanent)
+
+// ErrNoLocationLinks is returned when the start page loads but none of the
+// location link strategies match, so the crawl would find nothing. It is an
+// extraction failure: the page was reached, its markup was not understood.
+var ErrNoLocationLinks = fmt.Errorf("no location links found: %w", ErrExtraction)
+
+// Failure kinds attached to errors returned by the extraction helpers, so callers
+// can tell "no listings here" apart from "the page never loaded".
+var (
+	ErrNavigation = errors.New("navigation failed")
+	ErrExtraction = errors.New("extraction failed")
+	ErrTimeout    = errors.New("timed out")
+)
+
+// isRateLimited reports whether err is a 429 answer.
+func isRateLimited(err error) bool {
+	var limited *scraper.RateLimitedError
+	return errors.As(err, &limited)
+}
+
+// attemptsError records how many attempts retryWithBackoff made before
+// giving up on an operation.
+type attemptsError struct {
+	attempts int
+	err      error
+}
+
+func (e *attemptsError) Error() string { return e.err.Error() }
+func (e *attemptsError) Unwrap() error { return e.err }
+
+// attemptsOf reports how many attempts the operation that returned err made,
+// or 1 when err did not come out of retryWithBackoff.
+func attemptsOf(err error) int {
+	var ae *attemptsError
+	if errors.As(err, &ae) {
+		return ae.attempts
+	}
+	return 1
+}
+
+// permanentNetErrors are Chrome navigation failures that retrying won't fix.
+var permanentNetErrors = []string{
+	"net::ERR_NAME_NOT_RESOLVED",
+	"net::ERR_INVALID_URL",
+	"net::ERR_UNKNOWN_URL_SCHEME",
+	"Cannot navigate to invalid URL",
+}
+
+// isRetryable reports whether a failed chromedp operation is worth another attempt.
+func (s *ChromedpScraper) isRetryable(err error) bool {
+	if errors.Is(err, ErrCaptchaDetected) {
+		return s.cfg.Retry.RetryOnCaptcha
+	}
+	for _, msg := range permanentNetErrors {
+		if strings.Contains(err.Error(), msg) {
+			return false
+		}
+	}
+	return domain.IsRetryable(err)
+}
+
+// isFatal reports whether err means no other page can succeed either: the
+// browser is gone for good, or the scraper's parent context has ended.
+func (s *ChromedpScraper) isFatal(err error) bool {
+	return errors.Is(err, ErrBrowserDead) || s.parent.Err() != nil
+}
+
+// classify tags err with kind, or with ErrTimeout when a deadline expired.
+// Captcha errors are passed through untouched so retry logic can spot them.
+func classify(kind, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrCaptchaDetected):
+		return err
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	default:
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+}
+
+// isTimeout reports whether err was caused by an expired deadline.
+func isTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// tagged runs actions in order and classifies the first failure as kind.
+func tagged(kind error, actions ...chromedp.Action) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		for _, a := range actions {
+			if err := a.Do(ctx); err != nil {
+				return classify(kind, err)
+			}
+		}
+		return nil
+	}
+}
+
+// detectCaptcha runs the site's challenge check (js) on the current page and
+// returns ErrCaptchaDetected if it matches.
+func detectCaptcha(js string) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		var found bool
+		if err := chromedp.Evaluate(js, &found).Do(ctx); err != nil {
+			return err
+		}
+		if found {
+			return ErrCaptchaDetected
+		}
+		return nil
+	}
+}
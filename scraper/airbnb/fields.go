@@ -0,0 +1,82 @@
+package airbnb
+
+import (
+	"context"
+	"fmt"
+	"scraping-airbnb/scraper"
+	"scraping-airbnb/utils"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// timedField runs the actions extracting one field into val and records how
+// long they took and whether val came back empty, in the run metrics and as a
+// debug log, so slow or flaky extractors stand out.
+func (s *ChromedpScraper) timedField(name string, val *string, actions ...chromedp.Action) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		start := time.Now()
+		err := chromedp.Tasks(actions).Do(ctx)
+		elapsed := time.Since(start)
+		empty := strings.TrimSpace(*val) == ""
+		s.metrics.Load().recordField(name, elapsed, empty)
+		s.log.Debug("field extracted", "field", name, "duration", elapsed, "empty", empty)
+		return err
+	}
+}
+
+// timedPageLoad runs load, which navigates and waits for the page to settle,
+// and records how long it took in the run metrics.
+func (s *ChromedpScraper) timedPageLoad(load chromedp.Action) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		start := time.Now()
+		err := load.Do(ctx)
+		if err == nil {
+			s.metrics.Load().recordPageLoad(time.Since(start))
+		}
+		return err
+	}
+}
+
+// expandSection evaluates expandJS, which clicks a "show more" button and
+// reports whether it found one, then waits up to SectionWaitTimeout for the
+// modal it opens. Without a button there is no modal to wait for, and the
+// text is read from the page section instead.
+func (s *ChromedpScraper) expandSection(expandJS, modal string) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		var clicked bool
+		if err := chromedp.Evaluate(expandJS, &clicked).Do(ctx); err != nil || !clicked {
+			return ctx.Err()
+		}
+		return scraper.WaitVisibleUpTo(modal, s.cfg.Timing.SectionWaitTimeout).Do(ctx)
+	}
+}
+
+// retryEmptyFields re-runs the extractor of every required field that came back
+// empty, waiting FieldRetryDelay between attempts, up to FieldRetries times.
+// fields maps selector keys to the values extracted so far and is updated in
+// place. It fails with ErrExtraction if a required field is still empty.
+func (s *ChromedpScraper) retryEmptyFields(tab context.Context, url string, fields map[string]*string) error {
+	for _, field := range s.cfg.Scraper.RequiredFields {
+		value, ok := fields[field]
+		if !ok || *value != "" {
+			continue
+		}
+
+		for attempt := 1; attempt <= s.cfg.Scraper.FieldRetries && *value == ""; attempt++ {
+			s.log.Debug("required field empty; retrying", "url", url, "field", field, "attempt", attempt)
+			if err := chromedp.Run(tab,
+				chromedp.Sleep(s.cfg.Timing.FieldRetryDelay),
+				utils.SafeEvaluate(s.fieldJS(field), value),
+			); err != nil {
+				return classify(ErrExtraction, err)
+			}
+		}
+
+		if *value == "" {
+			return fmt.Errorf("%w: required field %q is empty", ErrExtraction, field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package airbnb
+
+import (
+	"context"
+	"scraping-airbnb/scraper"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// browserPingTimeout bounds the liveness check so a hung browser is detected quickly.
+const browserPingTimeout = 15 * time.Second
+
+// browser returns a context attached to the running Chrome, launching it on
+// first use. Every tab is opened from it, so they all live in one browser
+// that OpenTabs can inspect. If Chrome can't be started, the allocator is
+// returned instead and each tab tries to launch one itself, surfacing the
+// error where the tab is used.
+func (s *ChromedpScraper) browser() context.Context {
+	s.allocMu.RLock()
+	ctx := s.browserCtx
+	s.allocMu.RUnlock()
+	if ctx != nil {
+		return ctx
+	}
+
+	s.allocMu.Lock()
+	defer s.allocMu.Unlock()
+	if s.browserCtx != nil {
+		return s.browserCtx
+	}
+	ctx, cancel, err := scraper.LaunchBrowser(s.allocatorCtx)
+	if err != nil {
+		s.log.Warn("browser launch failed", "error", err)
+		return s.allocatorCtx
+	}
+	s.browserCtx, s.closeBrowser = ctx, cancel
+	return ctx
+}
+
+// restartBrowser closes Chrome and sets up a fresh allocator; the next call
+// to browser launches it again. The caller must hold allocMu.
+func (s *ChromedpScraper) restartBrowser() {
+	if s.closeBrowser != nil {
+		s.closeBrowser()
+		s.browserCtx, s.closeBrowser = nil, nil
+	}
+	s.allocCancel()
+	s.allocatorCtx, s.allocCancel = scraper.NewAllocator(s.parent, &s.cfg.Browser)
+}
+
+// pingBrowser opens a throwaway about:blank tab to check that Chrome still responds.
+func pingBrowser(browser context.Context) error {
+	if err := browser.Err(); err != nil {
+		return err
+	}
+	tab, cancel := scraper.NewTabWithTimeout(browser, browserPingTimeout)
+	defer cancel()
+	return chromedp.Run(tab, chromedp.Navigate("about:blank"))
+}
+
+// OpenTabs reports how many tabs the running browser has open, not counting
+// the blank one it started with. Compare it with the number of workers to
+// spot tabs that were never closed. It is 0 before the browser is launched.
+func (s *ChromedpScraper) OpenTabs() (int, error) {
+	s.allocMu.RLock()
+	browser := s.browserCtx
+	s.allocMu.RUnlock()
+	if browser == nil {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(browser, browserPingTimeout)
+	defer cancel()
+	targets, err := chromedp.Targets(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pages := 0
+	for _, t := range targets {
+		if t.Type == "page" {
+			pages++
+		}
+	}
+	return max(pages-1, 0), nil
+}
+
+// watchTargets logs OpenTabs every interval until ctx ends. More tabs than
+// the workers can account for, or a count that keeps climbing, points at a
+// tab whose context is never cancelled.
+func (s *ChromedpScraper) watchTargets(ctx context.Context, every time.Duration) {
+	// product workers, the card-link goroutines, plus the homepage and a spare
+	expected := s.cfg.Concurrency.ProductWorkers + cardLinkConcurrency + 2
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	peak := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := s.OpenTabs()
+		if err != nil {
+			s.log.Debug("open tab count failed", "error", err)
+			continue
+		}
+		peak = max(peak, n)
+		if n > expected {
+			s.log.Warn("more browser tabs open than workers account for; possible tab leak",
+				"tabs", n, "expected_max", expected, "peak", peak)
+			continue
+		}
+		s.log.Info("open browser tabs", "tabs", n, "peak", peak)
+	}
+}
+
+// ensureBrowser pings Chrome and restarts it if it is dead, so the scraper
+// self-heals from browser crashes instead of failing every retry.
+func (s *ChromedpScraper) ensureBrowser() {
+	browser := s.browser()
+	err := pingBrowser(browser)
+	if err == nil || s.parent.Err() != nil {
+		return
+	}
+
+	s.allocMu.Lock()
+	defer s.allocMu.Unlock()
+	if s.browserCtx != browser && s.allocatorCtx != browser {
+		// another worker already replaced it
+		return
+	}
+	s.log.Warn("browser unresponsive; restarting it", "error", err)
+	s.restartBrowser()
+}
+
+// browserRecycler restarts Chrome every BrowserConfig.RestartEvery pages so
+// memory it accumulates over a long run is reclaimed. Workers bracket each
+// page with begin and end; a restart waits for in-flight pages to finish and
+// holds new ones back until the fresh browser is up.
+type browserRecycler struct {
+	s     *ChromedpScraper
+	every int64
+	mu    sync.RWMutex
+	pages atomic.Int64
+}
+
+func (s *ChromedpScraper) newBrowserRecycler() *browserRecycler {
+	return &browserRecycler{s: s, every: int64(s.cfg.Browser.RestartEvery)}
+}
+
+// begin marks a page as in flight, waiting out any restart in progress.
+func (r *browserRecycler) begin() { r.mu.RLock() }
+
+// end marks a page as done and restarts the browser once enough have been scraped.
+func (r *browserRecycler) end() {
+	r.mu.RUnlock()
+	if r.every <= 0 || r.pages.Add(1) < r.every {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// another worker may have restarted it while this one waited for the lock
+	n := r.pages.Load()
+	if n < r.every || r.s.parent.Err() != nil {
+		return
+	}
+	r.s.log.Info("restarting browser to reclaim memory", "pages_since_restart", n)
+	r.s.allocMu.Lock()
+	r.s.restartBrowser()
+	r.s.allocMu.Unlock()
+	r.pages.Store(0)
+}
+
+// Close shuts down Chrome, waiting for the process to exit, and stops the
+// rate limiters. The scraper must not be used afterwards.
+func (s *ChromedpScraper) Close() {
+	s.stopWatch()
+	s.allocMu.Lock()
+	if s.closeBrowser != nil {
+		s.closeBrowser()
+	}
+	s.allocCancel()
+	s.allocMu.Unlock()
+	s.rateLimiter.Stop()
+	s.log.Info("browser closed")
+}
@@ -0,0 +1,214 @@
+package airbnb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics collects per-run counters and extraction latencies. Counters are
+// updated atomically from the worker goroutines.
+type Metrics struct {
+	started time.Time
+
+	locationsAttempted  atomic.Int64
+	locationsFailed     atomic.Int64
+	propertiesAttempted atomic.Int64
+	propertiesSucceeded atomic.Int64
+	propertiesFailed    atomic.Int64
+	propertiesTimedOut  atomic.Int64
+	retries             atomic.Int64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	pageLoads []time.Duration
+	fields    map[string]*FieldTiming
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{started: time.Now(), fields: make(map[string]*FieldTiming)}
+}
+
+// FieldTiming aggregates the extraction time of one product-page field.
+type FieldTiming struct {
+	Calls int64         `json:"calls"`
+	Empty int64         `json:"empty"`
+	Total time.Duration `json:"total_ns"`
+	Max   time.Duration `json:"max_ns"`
+}
+
+// Avg returns the mean extraction time.
+func (f FieldTiming) Avg() time.Duration {
+	if f.Calls == 0 {
+		return 0
+	}
+	return f.Total / time.Duration(f.Calls)
+}
+
+// recordField records one extraction of field, taking d, that came back empty or not.
+func (m *Metrics) recordField(field string, d time.Duration, empty bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.fields[field]
+	if !ok {
+		f = &FieldTiming{}
+		m.fields[field] = f
+	}
+	f.Calls++
+	if empty {
+		f.Empty++
+	}
+	f.Total += d
+	f.Max = max(f.Max, d)
+}
+
+// recordProperty records the outcome and latency of one product extraction.
+func (m *Metrics) recordProperty(d time.Duration, err error) {
+	m.propertiesAttempted.Add(1)
+	if err != nil {
+		m.propertiesFailed.Add(1)
+		if isTimeout(err) {
+			m.propertiesTimedOut.Add(1)
+		}
+		return
+	}
+	m.propertiesSucceeded.Add(1)
+
+	m.mu.Lock()
+	m.latencies = append(m.latencies, d)
+	m.mu.Unlock()
+}
+
+// recordPageLoad records how long a product page took to load and settle,
+// the part BrowserConfig.BlockResources speeds up.
+func (m *Metrics) recordPageLoad(d time.Duration) {
+	m.mu.Lock()
+	m.pageLoads = append(m.pageLoads, d)
+	m.mu.Unlock()
+}
+
+// recordLocation records the outcome of collecting card links for one location.
+func (m *Metrics) recordLocation(err error) {
+	m.locationsAttempted.Add(1)
+	if err != nil {
+		m.locationsFailed.Add(1)
+	}
+}
+
+// takeRetry counts one retry against budget and reports whether it is allowed.
+// A budget <= 0 is unlimited.
+func (m *Metrics) takeRetry(budget int64) bool {
+	if budget <= 0 {
+		m.retries.Add(1)
+		return true
+	}
+	for {
+		n := m.retries.Load()
+		if n >= budget {
+			return false
+		}
+		if m.retries.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// MetricsSnapshot is a point-in-time copy of Metrics, safe to print or marshal.
+type MetricsSnapshot struct {
+	LocationsAttempted  int64                  `json:"locations_attempted"`
+	LocationsFailed     int64                  `json:"locations_failed"`
+	PropertiesAttempted int64                  `json:"properties_attempted"`
+	PropertiesSucceeded int64                  `json:"properties_succeeded"`
+	PropertiesFailed    int64                  `json:"properties_failed"`
+	PropertiesTimedOut  int64                  `json:"properties_timed_out"`
+	Retries             int64                  `json:"retries"`
+	SuccessRate         float64                `json:"success_rate"`
+	LatencyP50          time.Duration          `json:"latency_p50_ns"`
+	LatencyP95          time.Duration          `json:"latency_p95_ns"`
+	PageLoadP50         time.Duration          `json:"page_load_p50_ns"`
+	PageLoadP95         time.Duration          `json:"page_load_p95_ns"`
+	Duration            time.Duration          `json:"duration_ns"`
+	Fields              map[string]FieldTiming `json:"fields"`
+}
+
+// Snapshot returns the current metric values.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	latencies := append([]time.Duration(nil), m.latencies...)
+	pageLoads := append([]time.Duration(nil), m.pageLoads...)
+	fields := make(map[string]FieldTiming, len(m.fields))
+	for name, f := range m.fields {
+		fields[name] = *f
+	}
+	m.mu.Unlock()
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	sort.Slice(pageLoads, func(i, j int) bool { return pageLoads[i] < pageLoads[j] })
+
+	snap := MetricsSnapshot{
+		LocationsAttempted:  m.locationsAttempted.Load(),
+		LocationsFailed:     m.locationsFailed.Load(),
+		PropertiesAttempted: m.propertiesAttempted.Load(),
+		PropertiesSucceeded: m.propertiesSucceeded.Load(),
+		PropertiesFailed:    m.propertiesFailed.Load(),
+		PropertiesTimedOut:  m.propertiesTimedOut.Load(),
+		Retries:             m.retries.Load(),
+		LatencyP50:          latencyPercentile(latencies, 50),
+		LatencyP95:          latencyPercentile(latencies, 95),
+		PageLoadP50:         latencyPercentile(pageLoads, 50),
+		PageLoadP95:         latencyPercentile(pageLoads, 95),
+		Duration:            time.Since(m.started),
+		Fields:              fields,
+	}
+	if snap.PropertiesAttempted > 0 {
+		snap.SuccessRate = float64(snap.PropertiesSucceeded) / float64(snap.PropertiesAttempted)
+	}
+	return snap
+}
+
+// latencyPercentile returns the nearest-rank p-th percentile of sorted durations.
+func latencyPercentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := (p*len(sorted)+99)/100 - 1
+	return sorted[max(idx, 0)]
+}
+
+// JSON returns the snapshot as indented JSON.
+func (s MetricsSnapshot) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// Print writes a human-readable summary of the snapshot to w.
+func (s MetricsSnapshot) Print(w io.Writer) {
+	fmt.Fprintf(w, "Run metrics (%s)\n", s.Duration.Round(time.Millisecond))
+	fmt.Fprintf(w, "  Locations:   %d attempted, %d failed\n", s.LocationsAttempted, s.LocationsFailed)
+	fmt.Fprintf(w, "  Properties:  %d attempted, %d succeeded, %d failed (%d timed out)\n",
+		s.PropertiesAttempted, s.PropertiesSucceeded, s.PropertiesFailed, s.PropertiesTimedOut)
+	fmt.Fprintf(w, "  Success:     %.1f%%\n", s.SuccessRate*100)
+	fmt.Fprintf(w, "  Retries:     %d\n", s.Retries)
+	fmt.Fprintf(w, "  Latency:     p50=%s p95=%s\n",
+		s.LatencyP50.Round(time.Millisecond), s.LatencyP95.Round(time.Millisecond))
+	fmt.Fprintf(w, "  Page load:   p50=%s p95=%s\n",
+		s.PageLoadP50.Round(time.Millisecond), s.PageLoadP95.Round(time.Millisecond))
+
+	if len(s.Fields) == 0 {
+		return
+	}
+	// slowest in total first, so the bottleneck leads
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return s.Fields[names[i]].Total > s.Fields[names[j]].Total })
+	fmt.Fprintf(w, "  Fields:\n")
+	for _, name := range names {
+		f := s.Fields[name]
+		fmt.Fprintf(w, "    %-14s avg=%s max=%s empty=%d/%d\n",
+			name, f.Avg().Round(time.Millisecond), f.Max.Round(time.Millisecond), f.Empty, f.Calls)
+	}
+}
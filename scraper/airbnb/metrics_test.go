@@ -0,0 +1,60 @@
+package airbnb
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTakeRetryStopsAtBudget(t *testing.T) {
+	m := newMetrics()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m.takeRetry(5) {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 5 {
+		t.Errorf("takeRetry allowed %d retries, want 5", allowed)
+	}
+	if !newMetrics().takeRetry(0) {
+		t.Error("takeRetry(0) = false, want unlimited")
+	}
+}
+
+func TestRecordFieldAggregates(t *testing.T) {
+	m := newMetrics()
+	m.recordField("title", 10*time.Millisecond, false)
+	m.recordField("title", 30*time.Millisecond, true)
+
+	got := m.Snapshot().Fields["title"]
+	if got.Calls != 2 || got.Empty != 1 {
+		t.Errorf("calls/empty = %d/%d, want 2/1", got.Calls, got.Empty)
+	}
+	if got.Avg() != 20*time.Millisecond || got.Max != 30*time.Millisecond {
+		t.Errorf("avg/max = %s/%s, want 20ms/30ms", got.Avg(), got.Max)
+	}
+}
+
+func TestPageLoadPercentiles(t *testing.T) {
+	m := newMetrics()
+	for _, ms := range []int{400, 100, 300, 200} {
+		m.recordPageLoad(time.Duration(ms) * time.Millisecond)
+	}
+
+	snap := m.Snapshot()
+	if snap.PageLoadP50 != 200*time.Millisecond || snap.PageLoadP95 != 400*time.Millisecond {
+		t.Errorf("page load p50/p95 = %s/%s, want 200ms/400ms", snap.PageLoadP50, snap.PageLoadP95)
+	}
+}
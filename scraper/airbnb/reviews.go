@@ -0,0 +1,71 @@
+package airbnb
+
+import (
+	"context"
+	"fmt"
+	"scraping-airbnb/scraper"
+	"scraping-airbnb/utils"
+
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+// scrapeReviews opens the listing's reviews modal in tab, scrolls it until
+// ScraperConfig.MaxReviews reviews have loaded (or no more do), and returns
+// their texts. A listing without the modal, e.g. one with no reviews yet, is
+// given up on after SectionWaitTimeout. Failures are logged and yield no
+// reviews rather than failing the listing, which has already been extracted.
+func (s *ChromedpScraper) scrapeReviews(tab context.Context, url string) []string {
+	limit := s.cfg.Scraper.MaxReviews
+	if limit <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(tab, s.cfg.Timing.ProductTimeout)
+	defer cancel()
+
+	page := s.site.ProductPage()
+	var open bool
+	var reviews []string
+	err := chromedp.Run(ctx,
+		// close the description modal, which would otherwise cover the page
+		chromedp.KeyEvent(kb.Escape),
+		utils.SafeEvaluate(page.OpenReviewsJS, nil),
+		scraper.WaitVisibleUpTo(page.ReviewsModal, s.cfg.Timing.SectionWaitTimeout),
+		chromedp.Evaluate(presentJS(page.ReviewsModal), &open),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if !open {
+				return nil
+			}
+			return chromedp.Tasks{
+				scraper.ScrollElementUntil(&s.cfg.Timing, page.ReviewsScrollerJS,
+					fmt.Sprintf(`(%s).length >= %d`, page.ReviewsJS, limit),
+					s.cfg.Scraper.ScrollStep, s.cfg.Scraper.MaxScrollIterations),
+				chromedp.Evaluate(page.ReviewsJS, &reviews),
+			}.Do(ctx)
+		}),
+	)
+	if err != nil {
+		s.log.Warn("reviews not extracted", "url", url, "error", classify(ErrExtraction, err))
+		return nil
+	}
+	if !open {
+		s.log.Debug("no reviews modal", "url", url)
+	}
+	return reviewTexts(open, reviews, limit)
+}
+
+// reviewTexts cleans up to limit of the scraped reviews. With no modal open
+// there is nothing to read, so it returns none.
+func reviewTexts(modalOpen bool, reviews []string, limit int) []string {
+	if !modalOpen {
+		return nil
+	}
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+	}
+	for i, r := range reviews {
+		reviews[i] = utils.CleanText(r)
+	}
+	return reviews
+}
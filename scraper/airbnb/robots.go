@@ -0,0 +1,23 @@
+package airbnb
+
+import "context"
+
+// allowedByRobots reports whether url may be fetched under robots.txt, logging
+// any URL it skips. Always true when RespectRobots is off. If robots.txt can't
+// be fetched or parsed, the URL is skipped too: the check fails closed, and
+// -ignore-robots is the way to crawl without it.
+func (s *ChromedpScraper) allowedByRobots(ctx context.Context, url string) bool {
+	if s.robots == nil {
+		return true
+	}
+
+	ok, err := s.robots.Allowed(ctx, url)
+	if err != nil {
+		s.log.Warn("skipping url: robots.txt unavailable", "url", url, "error", err)
+		return false
+	}
+	if !ok {
+		s.log.Info("skipping url disallowed by robots.txt", "url", url)
+	}
+	return ok
+}
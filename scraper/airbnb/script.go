@@ -1,15 +1,57 @@
 package airbnb
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"scraping-airbnb/utils"
+)
 
 // ── Location page JS ──────────────────────────────────────────────────────────
 
-// locationLinksJS collects all location card hrefs from the Airbnb homepage.
-const locationLinksJS = `
-JSON.stringify(
-	Array.from(document.querySelectorAll('.c1ol07tf a'))
-		.map(a => ({ url: a.href }))
-)
+// searchPathRe matches Airbnb search result paths such as /s/Paris--France/homes.
+const searchPathRe = `/\/s\/[^/]+\/homes/`
+
+// locationLinksJS returns JS collecting the location links on the Airbnb
+// homepage. The first selector matching any anchors wins; if none do, it falls
+// back to links inside headings, then anywhere, whose href is a search page,
+// so a renamed class doesn't leave the run with nothing to crawl.
+func locationLinksJS(selectors []string) string {
+	list, _ := json.Marshal(selectors)
+	return fmt.Sprintf(`
+(()=>{
+	const isSearch = a => %s.test(new URL(a.href, location.href).pathname);
+	const links = anchors => JSON.stringify(anchors.map(a => ({ url: a.href })));
+	for (const sel of %s) {
+		const found = Array.from(document.querySelectorAll(sel)).filter(a => a.href);
+		if (found.length) return links(found);
+	}
+	for (const sel of ['h2 a, h3 a', 'a']) {
+		const found = Array.from(document.querySelectorAll(sel)).filter(a => a.href && isSearch(a));
+		if (found.length) return links(found);
+	}
+	return "[]";
+})()
+`, searchPathRe, list)
+}
+
+// presentJS returns JS reporting whether an element matches sel.
+func presentJS(sel string) string {
+	quoted, _ := json.Marshal(sel)
+	return fmt.Sprintf(`document.querySelector(%s) !== null`, quoted)
+}
+
+// ── Bot-check detection JS ────────────────────────────────────────────────────
+
+// captchaJS reports whether the page is a captcha / "are you a human" challenge.
+const captchaJS = `
+(()=>{
+	const text = document.body?.innerText || "";
+	if (/confirm you.re a human|are you a human|verify you are human/i.test(text)) return true;
+	for (const sel of ['#px-captcha', 'iframe[src*="captcha"]', 'iframe[title*="challenge" i]']) {
+		if (document.querySelector(sel)) return true;
+	}
+	return false;
+})()
 `
 
 // ── Listing search page JS ────────────────────────────────────────────────────
@@ -58,50 +100,93 @@ JSON.stringify({
 // ── Product detail page JS ────────────────────────────────────────────────────
 // Each selector tries the most specific/stable target first, then falls back.
 
-// titleJS extracts the listing title from the h1.
-const titleJS = `document.querySelector('.tglziin > h1')?.innerText?.trim() || ""`
-
-// priceJS extracts the nightly price, trying known class names in order.
-const priceJS = `
+// firstTextJS returns JS that tries selectors in order and returns the trimmed
+// text (read via prop: "innerText" or "textContent") of the first match, or "".
+// The selector lists come from ScraperConfig.Selectors so they can be changed
+// without a rebuild.
+func firstTextJS(selectors []string, prop string) string {
+	list, _ := json.Marshal(selectors)
+	return fmt.Sprintf(`
 (()=>{
-	for (const sel of ['.u1opajno', '.u174bpcy', '.uhx2ipv']) {
-		const text = document.querySelector(sel)?.textContent?.trim();
+	for (const sel of %s) {
+		const text = document.querySelector(sel)?.%s?.trim();
 		if (text) return text;
 	}
 	return "";
 })()
-`
-
-// for how many nights text scrape
+`, list, prop)
+}
 
-const nightsJS = `(()=>{
-	for (const sel of ['.q5ltwoj', '.q1tsro90 > span', '.qesosmo']) {
-		const text = document.querySelector(sel)?.textContent?.trim();
-		if (text) return text;
+// priceLabelJS returns JS that finds the first price matching selectors and
+// returns the text of the nearest of its ancestors (up to three levels) with
+// a per-night marker or "total" (utils.PriceLabelPattern), e.g. "$120 night",
+// or "" when none has. A "for 4 nights" breakdown alone doesn't count. The
+// result is parsed by utils.ParsePriceType.
+func priceLabelJS(selectors []string) string {
+	list, _ := json.Marshal(selectors)
+	pattern, _ := json.Marshal(utils.PriceLabelPattern)
+	return fmt.Sprintf(`
+(()=>{
+	const label = new RegExp(%s, "i");
+	for (const sel of %s) {
+		let el = document.querySelector(sel);
+		for (let depth = 0; el && depth <= 3; depth++, el = el.parentElement) {
+			const text = el.textContent?.trim() || "";
+			if (label.test(text)) return text;
+		}
 	}
 	return "";
 })()
+`, pattern, list)
+}
+
+// expandDescriptionJS clicks "Show more about this place" to open the full
+// description, and reports whether there was a button to click.
+const expandDescriptionJS = `
+(() => {
+	const btn = document.querySelector('button[aria-label="Show more about this place"]');
+	if (btn) btn.click();
+	return !!btn;
+})()
 `
 
+// descriptionModalSelector matches the dialog opened by "Show more about this place".
+const descriptionModalSelector = `div[role="dialog"]`
 
-// locationJS extracts the listing location/neighbourhood.
-const locationJS = `
-(()=>{
-	for (const sel of ['._1t2xqmi > h3', '.s1qk96pm']) {
-		const text = document.querySelector(sel)?.textContent?.trim();
-		if (text) return text;
+// descriptionJS reads the full description, preferring the expanded
+// "About this place" modal over the clamped text in the page section.
+const descriptionJS = `
+(() => {
+	for (const dialog of document.querySelectorAll('div[role="dialog"]')) {
+		const text = dialog.innerText.trim();
+		if (/about this (place|space)/i.test(text)) {
+			// drop the modal heading line
+			return text.replace(/^about this (place|space)\s*/i, "").trim();
+		}
 	}
-	return "";
+
+	const container = document.querySelector(
+		'div[data-section-id="DESCRIPTION_DEFAULT"]'
+	);
+	if (!container) return "";
+
+	// strip the "Show more" button from a copy before reading
+	const clone = container.cloneNode(true);
+	const btn = clone.querySelector('button[aria-label="Show more about this place"]');
+	if (btn) btn.remove();
+
+	return clone.innerText.trim();
 })()
 `
 
-// ratingJS extracts the host/listing rating.
-const ratingJS = `
+// propertyTypeJS extracts the overview heading shown under the title,
+// e.g. "Entire rental unit in Paris, France" or "Private room in home".
+const propertyTypeJS = `
 (()=>{
 	for (const sel of [
-		'[data-testid="pdp-reviews-highlight-banner-host-rating"] div[aria-hidden="true"]',
-		'.rmtgcc3',
-		'.r1lcxetl'
+		'div[data-section-id="OVERVIEW_DEFAULT_V2"] h2',
+		'div[data-section-id="OVERVIEW_DEFAULT"] h2',
+		'div[data-plugin-in-point-id="OVERVIEW_DEFAULT_V2"] h2'
 	]) {
 		const text = document.querySelector(sel)?.textContent?.trim();
 		if (text) return text;
@@ -110,22 +195,61 @@ const ratingJS = `
 })()
 `
 
-const descriptionJS = `
-(() => {
-
-    const container = document.querySelector(
-        'div[data-section-id="DESCRIPTION_DEFAULT"]'
-    );
-
-    if (!container) return "";
+// coordinatesJS returns whatever in the location section carries the map
+// coordinates: a "lat,lng" pair from data attributes, or the static map image
+// / Google Maps link URL. The result is parsed by utils.ParseCoordinates.
+const coordinatesJS = `
+(()=>{
+	const section = document.querySelector('div[data-section-id="LOCATION_DEFAULT"]');
+	if (!section) return "";
+	const tagged = section.querySelector('[data-lat][data-lng]');
+	if (tagged) return tagged.dataset.lat + "," + tagged.dataset.lng;
+	for (const sel of ['img[src*="center="]', 'img[src*="staticmap"]', 'a[href*="maps.google"]', 'a[href*="google.com/maps"]']) {
+		const el = section.querySelector(sel);
+		const url = el?.src || el?.href;
+		if (url) return url;
+	}
+	return "";
+})()
+`
 
-    // remove "Registration Details" label if exists
-    const clone = container.cloneNode(true);
+// ── Reviews modal JS ──────────────────────────────────────────────────────────
 
-	const btn = clone.querySelector('button[aria-label="Show more about this place"]');
-	if (btn) btn.remove();
+// openReviewsJS clicks "Show all N reviews" to open the reviews modal.
+const openReviewsJS = `
+(() => {
+	const btn = document.querySelector('[data-testid="pdp-show-all-reviews-button"] button, a[href*="/reviews"]')
+		|| Array.from(document.querySelectorAll('button, a'))
+			.find(el => /show all \d[\d,.]* reviews/i.test(el.textContent));
+	if (btn) btn.click();
+})()
+`
 
-    return clone.innerText.trim();
+// reviewsModalSelector matches the dialog opened by "Show all N reviews".
+const reviewsModalSelector = `div[role="dialog"] [data-review-id]`
 
+// reviewsScrollerJS evaluates to the reviews modal's scroll container: the
+// first element inside the dialog whose content overflows it vertically.
+const reviewsScrollerJS = `
+(() => {
+	const dialog = document.querySelector('div[role="dialog"]');
+	if (!dialog) return null;
+	return Array.from(dialog.querySelectorAll('*')).find(el => {
+		const overflow = getComputedStyle(el).overflowY;
+		return (overflow === "auto" || overflow === "scroll") && el.scrollHeight > el.clientHeight;
+	}) || null;
 })()
-`
\ No newline at end of file
+`
+
+// reviewsJS collects the body text of every review loaded in the modal.
+const reviewsJS = `
+(() => Array.from(document.querySelectorAll('div[role="dialog"] [data-review-id]'))
+	.map(review => {
+		// the body is the longest text block; name, date and stay details are short
+		const blocks = Array.from(review.querySelectorAll('span, div'))
+			.filter(el => el.children.length === 0)
+			.map(el => el.innerText.trim());
+		return blocks.reduce((a, b) => b.length > a.length ? b : a, "");
+	})
+	.filter(text => text))()
+`
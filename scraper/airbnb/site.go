@@ -0,0 +1,136 @@
+package airbnb
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"scraping-airbnb/config"
+	"scraping-airbnb/scraper"
+	"strings"
+	"time"
+)
+
+// Site is the Airbnb implementation of scraper.SiteScraper.
+type Site struct{}
+
+var _ scraper.SiteScraper = Site{}
+
+func (Site) Platform() string { return "Airbnb" }
+
+func (Site) DefaultSelectors() map[string][]string { return config.DefaultSelectors() }
+
+func (Site) LocationLinksJS(selectors []string) string { return locationLinksJS(selectors) }
+
+func (Site) CardLinksJS(limit int) string { return cardLinksJS(limit) }
+
+func (Site) NextPageJS() string { return nextPageJS }
+
+func (Site) CaptchaJS() string { return captchaJS }
+
+func (Site) ProductPage() scraper.ProductPage {
+	return scraper.ProductPage{
+		TitleSection:        `div[data-plugin-in-point-id="TITLE_DEFAULT"]`,
+		BookingSection:      `div[data-testid="book-it-default"]`,
+		LocationSection:     `div[data-section-id="LOCATION_DEFAULT"]`,
+		PropertyTypeJS:      propertyTypeJS,
+		CoordinatesJS:       coordinatesJS,
+		ExpandDescriptionJS: expandDescriptionJS,
+		DescriptionModal:    descriptionModalSelector,
+		DescriptionJS:       descriptionJS,
+		OpenReviewsJS:       openReviewsJS,
+		ReviewsModal:        reviewsModalSelector,
+		ReviewsScrollerJS:   reviewsScrollerJS,
+		ReviewsJS:           reviewsJS,
+	}
+}
+
+// IsListingURL reports whether rawURL is an Airbnb room page: a regular,
+// Plus or Luxe listing path with a room id.
+func (Site) IsListingURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return roomIDPath.MatchString(u.Path)
+}
+
+// CanonicalURL reduces a listing URL to its stable form; see CanonicalizeListingURL.
+func (Site) CanonicalURL(rawURL string) string { return CanonicalizeListingURL(rawURL) }
+
+// ListingID returns the Airbnb room id; see ParseRoomID.
+func (Site) ListingID(rawURL string) (string, error) { return ParseRoomID(rawURL) }
+
+// roomIDPath matches regular, Plus and Luxe listing paths, capturing the
+// kind of path and the room id.
+var roomIDPath = regexp.MustCompile(`^/(rooms(?:/plus)?|luxury/listing)/(\d+)`)
+
+// ParseRoomID extracts the numeric room id from an Airbnb listing URL, e.g.
+// "12345678" from https://www.airbnb.com/rooms/12345678?adults=2. Plus
+// (/rooms/plus/<id>) and Luxe (/luxury/listing/<id>) URLs are accepted too.
+func ParseRoomID(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("parse %q: %w: %w", rawURL, ErrNoRoomID, err)
+	}
+	m := roomIDPath.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", fmt.Errorf("%q: %w", rawURL, ErrNoRoomID)
+	}
+	return m[2], nil
+}
+
+// CanonicalizeListingURL reduces an Airbnb listing URL to
+// https://<host>/rooms/<id> (https://<host>/luxury/listing/<id> for Luxe),
+// dropping search parameters, fragments, trailing path segments and letter
+// case differences in the host, so the same listing found through different
+// searches is stored under one URL. Anything that is not a listing URL is
+// returned unchanged.
+func CanonicalizeListingURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	m := roomIDPath.FindStringSubmatch(u.Path)
+	if m == nil {
+		return raw
+	}
+	path := "/rooms/"
+	if m[1] == "luxury/listing" {
+		path = "/luxury/listing/"
+	}
+	return "https://" + strings.ToLower(u.Host) + path + m[2]
+}
+
+// DatedURL sets Airbnb's check_in and check_out query parameters.
+func (Site) DatedURL(rawURL string, checkIn, checkOut time.Time) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	q.Set("check_in", checkIn.Format(time.DateOnly))
+	q.Set("check_out", checkOut.Format(time.DateOnly))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// LocalizeURL sets Airbnb's locale and currency query parameters, replacing
+// any already present, so prices and text don't depend on the inferred region.
+func (Site) LocalizeURL(rawURL, locale, currency string) string {
+	if locale == "" && currency == "" {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	if locale != "" {
+		q.Set("locale", locale)
+	}
+	if currency != "" {
+		q.Set("currency", currency)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,109 @@
+package airbnb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalizeURL(t *testing.T) {
+	tests := []struct {
+		name             string
+		in               string
+		locale, currency string
+		want             string
+	}{
+		{"adds params", "https://www.airbnb.com/rooms/1", "en", "USD", "https://www.airbnb.com/rooms/1?currency=USD&locale=en"},
+		{"replaces params", "https://www.airbnb.com/s/Paris/homes?currency=EUR&adults=2", "", "USD", "https://www.airbnb.com/s/Paris/homes?adults=2&currency=USD"},
+		{"unset", "https://www.airbnb.com/rooms/1?currency=EUR", "", "", "https://www.airbnb.com/rooms/1?currency=EUR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Site{}).LocalizeURL(tt.in, tt.locale, tt.currency); got != tt.want {
+				t.Errorf("LocalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeListingURL(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"already canonical", "https://www.airbnb.com/rooms/12345", "https://www.airbnb.com/rooms/12345"},
+		{"search params", "https://www.airbnb.com/rooms/12345?adults=2&check_in=2025-07-01&check_out=2025-07-05&source_impression_id=p3_1718", "https://www.airbnb.com/rooms/12345"},
+		{"fragment and trailing slash", "https://www.airbnb.com/rooms/12345/#availability-calendar", "https://www.airbnb.com/rooms/12345"},
+		{"photos subpage", "https://www.airbnb.com/rooms/12345/photos?modal=PHOTO_TOUR", "https://www.airbnb.com/rooms/12345"},
+		{"plus listing", "https://www.airbnb.com/rooms/plus/678?guests=1", "https://www.airbnb.com/rooms/678"},
+		{"luxe listing", "https://www.airbnb.com/luxury/listing/4242?check_in=2025-07-01&source_impression_id=p3_1718", "https://www.airbnb.com/luxury/listing/4242"},
+		{"luxe subpage", "https://www.airbnb.com/luxury/listing/4242/photos#gallery", "https://www.airbnb.com/luxury/listing/4242"},
+		{"http and mixed case host", "http://WWW.Airbnb.com/rooms/12345", "https://www.airbnb.com/rooms/12345"},
+		{"regional domain", "https://www.airbnb.co.uk/rooms/12345?currency=GBP", "https://www.airbnb.co.uk/rooms/12345"},
+		{"surrounding whitespace", "  https://www.airbnb.com/rooms/12345?adults=1\n", "https://www.airbnb.com/rooms/12345"},
+		{"search page unchanged", "https://www.airbnb.com/s/Paris/homes?adults=2", "https://www.airbnb.com/s/Paris/homes?adults=2"},
+		{"relative unchanged", "/rooms/12345", "/rooms/12345"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalizeListingURL(tt.in); got != tt.want {
+				t.Errorf("CanonicalizeListingURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsListingURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"https://www.airbnb.com/rooms/12345?adults=2", true},
+		{"https://www.airbnb.com/rooms/plus/678", true},
+		{"https://www.airbnb.com/luxury/listing/4242?guests=2", true},
+		{"https://www.airbnb.com/s/Paris/homes", false},
+		{"https://www.airbnb.com/luxury", false},
+		{"https://www.airbnb.com/rooms/abc", false},
+	}
+	for _, tt := range tests {
+		if got := (Site{}).IsListingURL(tt.in); got != tt.want {
+			t.Errorf("IsListingURL(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRoomID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://www.airbnb.com/rooms/12345678", "12345678"},
+		{"https://www.airbnb.com/rooms/12345678?adults=2&check_in=2025-07-01", "12345678"},
+		{"https://www.airbnb.com/rooms/plus/678/photos", "678"},
+		{"https://www.airbnb.com/luxury/listing/4242?guests=2", "4242"},
+	}
+	for _, tt := range tests {
+		got, err := ParseRoomID(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRoomID(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"https://www.airbnb.com/s/Paris/homes", "https://www.airbnb.com/rooms/abc", "://bad"} {
+		if _, err := ParseRoomID(in); err == nil {
+			t.Errorf("ParseRoomID(%q) error = nil, want error", in)
+		}
+	}
+}
+
+func TestDatedURL(t *testing.T) {
+	checkIn := time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC)
+	checkOut := time.Date(2025, 7, 6, 0, 0, 0, 0, time.UTC)
+	got := (Site{}).DatedURL("https://www.airbnb.com/rooms/1?adults=2&check_in=2025-01-01", checkIn, checkOut)
+	want := "https://www.airbnb.com/rooms/1?adults=2&check_in=2025-07-04&check_out=2025-07-06"
+	if got != want {
+		t.Errorf("DatedURL() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package airbnb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"scraping-airbnb/config"
+	"scraping-airbnb/scraper"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// SelectorResult reports what one product-page extractor returned.
+type SelectorResult struct {
+	Name  string
+	Value string
+	// Optional extractors may legitimately be empty (e.g. nights without dates)
+	Optional bool
+}
+
+// Matched reports whether the extractor found anything.
+func (r SelectorResult) Matched() bool {
+	return strings.TrimSpace(r.Value) != ""
+}
+
+// productExtractor is one named product-page extraction script.
+type productExtractor struct {
+	name     string
+	js       string
+	optional bool
+}
+
+// productExtractors lists every product-page extractor in the order
+// extractProperty runs them, using the configured selectors.
+func (s *ChromedpScraper) productExtractors() []productExtractor {
+	page := s.site.ProductPage()
+	return []productExtractor{
+		{config.SelectorTitle, s.fieldJS(config.SelectorTitle), false},
+		{config.SelectorPrice, s.fieldJS(config.SelectorPrice), false},
+		{"price label", priceLabelJS(s.fieldSelectors(config.SelectorPrice)), true},
+		{config.SelectorNights, s.fieldJS(config.SelectorNights), true},
+		{config.SelectorRating, s.fieldJS(config.SelectorRating), true},
+		{"property type", page.PropertyTypeJS, false},
+		{config.SelectorLocation, s.fieldJS(config.SelectorLocation), false},
+		{"coordinates", page.CoordinatesJS, true},
+		{"description", page.DescriptionJS, false},
+	}
+}
+
+// ValidateSelectors opens a single listing page and runs every product-page
+// extractor against it, without the WaitVisible gates that would abort on the
+// first broken selector. Nothing is saved; use it as a quick health check
+// after Airbnb changes its markup.
+func (s *ChromedpScraper) ValidateSelectors(ctx context.Context, url string) ([]SelectorResult, error) {
+	tab, cancel := scraper.NewTabWithTimeout(s.browser(), s.cfg.Timing.ProductTimeout)
+	defer cancel()
+
+	err := chromedp.Run(tab,
+		tagged(ErrNavigation,
+			scraper.BlockResources(s.cfg.Browser.BlockResources),
+			s.maskFingerprint(),
+			s.setTabUserAgent(),
+			s.setTabHeaders(),
+			scraper.NavigateAndSettle(s.localURL(url), &s.cfg.Timing, s.cfg.Timing.ProductPageWait),
+			detectCaptcha(s.site.CaptchaJS()),
+			// lazy sections such as the location map only render once scrolled into view
+			s.scrollPage(),
+			chromedp.Evaluate(`window.scrollTo(0, 0)`, nil),
+			chromedp.Sleep(s.cfg.Timing.AfterScrollWait),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("validate selectors %s: %w", url, err)
+	}
+
+	extractors := s.productExtractors()
+	results := make([]SelectorResult, 0, len(extractors))
+	for _, e := range extractors {
+		var value string
+		if err := chromedp.Run(tab, chromedp.Evaluate(e.js, &value)); err != nil {
+			s.log.Warn("extractor failed", "name", e.name, "error", err)
+		}
+		results = append(results, SelectorResult{Name: e.name, Value: value, Optional: e.optional})
+	}
+
+	return results, nil
+}
+
+// PrintSelectorReport writes one line per extractor. Required extractors that
+// came back empty are marked EMPTY, optional ones "empty".
+func PrintSelectorReport(w io.Writer, results []SelectorResult) {
+	for _, r := range results {
+		status := "OK   "
+		switch {
+		case r.Matched():
+		case r.Optional:
+			status = "empty"
+		default:
+			status = "EMPTY"
+		}
+		value := strings.Join(strings.Fields(r.Value), " ")
+		if len(value) > 60 {
+			value = value[:57] + "..."
+		}
+		fmt.Fprintf(w, "  %s  %-14s %s\n", status, r.Name, value)
+	}
+}
@@ -2,16 +2,24 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"scraping-airbnb/config"
+	"strings"
 	"time"
 
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 )
 
-// newAllocator creates a shared Chrome process from the given browser config.
-// All tabs (contexts) must be created from the returned context.
-func NewAllocator(parent context.Context, cfg *config.BrowserConfig) context.Context {
+// NewAllocator creates a shared Chrome process from the given browser config.
+// All tabs (contexts) must be created from the returned context. The cancel
+// func shuts Chrome down and blocks until the process has exited.
+func NewAllocator(parent context.Context, cfg *config.BrowserConfig) (context.Context, context.CancelFunc) {
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", cfg.Headless),
 		chromedp.Flag("disable-gpu", cfg.DisableGPU),
@@ -20,19 +28,91 @@ func NewAllocator(parent context.Context, cfg *config.BrowserConfig) context.Con
 		chromedp.Flag("disable-dev-shm-usage", cfg.DisableShm),
 		chromedp.UserAgent(cfg.UserAgent),
 	)
-	allocCtx, _ := chromedp.NewExecAllocator(parent, opts...)
-	return allocCtx
+	if cfg.WindowWidth > 0 && cfg.WindowHeight > 0 {
+		opts = append(opts, chromedp.WindowSize(cfg.WindowWidth, cfg.WindowHeight))
+	}
+	return chromedp.NewExecAllocator(parent, opts...)
+}
+
+// blockableResources maps BrowserConfig.BlockResources names to CDP resource types.
+var blockableResources = map[string]network.ResourceType{
+	"image":      network.ResourceTypeImage,
+	"media":      network.ResourceTypeMedia,
+	"font":       network.ResourceTypeFont,
+	"stylesheet": network.ResourceTypeStylesheet,
+	"script":     network.ResourceTypeScript,
+	"ping":       network.ResourceTypePing,
+	"other":      network.ResourceTypeOther,
+}
+
+// ValidateBlockResources returns an error naming the first entry of types
+// that BlockResources does not know.
+func ValidateBlockResources(types []string) error {
+	for _, t := range types {
+		if _, ok := blockableResources[strings.ToLower(t)]; !ok {
+			return fmt.Errorf("unknown resource type %q (want image, media, font, stylesheet, script, ping or other)", t)
+		}
+	}
+	return nil
+}
+
+// BlockResources makes the tab abort every request for the given resource
+// types (see BrowserConfig.BlockResources) until it is closed. Run it once per
+// tab; each run adds another interception listener.
+func BlockResources(types []string) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		if len(types) == 0 {
+			return nil
+		}
+		if err := ValidateBlockResources(types); err != nil {
+			return err
+		}
+		patterns := make([]*fetch.RequestPattern, len(types))
+		for i, t := range types {
+			patterns[i] = &fetch.RequestPattern{URLPattern: "*", ResourceType: blockableResources[strings.ToLower(t)]}
+		}
+
+		chromedp.ListenTarget(ctx, func(ev any) {
+			if e, ok := ev.(*fetch.EventRequestPaused); ok {
+				// listeners must not block, so answer from a goroutine
+				go fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+			}
+		})
+		return fetch.Enable().WithPatterns(patterns).Do(ctx)
+	}
+}
+
+// LaunchBrowser starts Chrome from the allocator context and returns a
+// context attached to it. Tabs opened from that context share the one Chrome
+// process; cancelling it closes the browser.
+func LaunchBrowser(allocCtx context.Context) (context.Context, context.CancelFunc, error) {
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("launch browser: %w", err)
+	}
+	return ctx, cancel, nil
+}
+
+// tabOptions gives a tab opened from a running browser its own browser
+// context, so tabs don't share cookies. From an allocator context the tab
+// launches its own Chrome, which is isolated already.
+func tabOptions(parent context.Context) []chromedp.ContextOption {
+	if c := chromedp.FromContext(parent); c != nil && c.Browser != nil {
+		return []chromedp.ContextOption{chromedp.WithNewBrowserContext()}
+	}
+	return nil
 }
 
-// newTab opens a new browser tab from the allocator context.
-func NewTab(allocCtx context.Context) (context.Context, context.CancelFunc) {
-	return chromedp.NewContext(allocCtx)
+// newTab opens a new browser tab from a browser or allocator context.
+func NewTab(parent context.Context) (context.Context, context.CancelFunc) {
+	return chromedp.NewContext(parent, tabOptions(parent)...)
 }
 
 // newTabWithTimeout opens a browser tab that auto-cancels after the given duration.
-func NewTabWithTimeout(allocCtx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
-	tCtx, tCancel := context.WithTimeout(allocCtx, timeout)
-	bCtx, bCancel := chromedp.NewContext(tCtx)
+func NewTabWithTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	tCtx, tCancel := context.WithTimeout(parent, timeout)
+	bCtx, bCancel := chromedp.NewContext(tCtx, tabOptions(tCtx)...)
 	return bCtx, func() {
 		bCancel()
 		tCancel()
@@ -55,11 +135,199 @@ func ScrollToBottom(cfg *config.TimingConfig, scrollStep int) chromedp.ActionFun
 			).Do(ctx); err != nil {
 				return fmt.Errorf("scrollToBottom: scroll to %d: %w", y, err)
 			}
-			time.Sleep(cfg.ScrollStepDelay)
+			if err := sleepCtx(ctx, cfg.ScrollStepDelay); err != nil {
+				return err
+			}
 		}
 
 		// Final pause so last lazy-loaded items have time to render
-		time.Sleep(cfg.ScrollBottomWait)
+		return sleepCtx(ctx, cfg.ScrollBottomWait)
+	}
+}
+
+// ScrollUntilStable keeps scrolling while the page grows, re-reading scrollHeight
+// on every step. It stops once the bottom has been reached and the height did not
+// increase during a ScrollBottomWait pause, or after maxIterations steps.
+func ScrollUntilStable(cfg *config.TimingConfig, scrollStep, maxIterations int) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		y := 0
+		bottomHeight := -1
+
+		for i := 0; i < maxIterations; i++ {
+			var height int
+			if err := chromedp.Evaluate(`document.body.scrollHeight`, &height).Do(ctx); err != nil {
+				return fmt.Errorf("scrollUntilStable: get height: %w", err)
+			}
+
+			if y > height {
+				// at the bottom: stop if nothing new loaded since we last got here
+				if height == bottomHeight {
+					return nil
+				}
+				bottomHeight = height
+				if err := sleepCtx(ctx, cfg.ScrollBottomWait); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := chromedp.Evaluate(
+				fmt.Sprintf(`window.scrollTo(0, %d)`, y), nil,
+			).Do(ctx); err != nil {
+				return fmt.Errorf("scrollUntilStable: scroll to %d: %w", y, err)
+			}
+			y += scrollStep
+			if err := sleepCtx(ctx, cfg.ScrollStepDelay); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// ScrollElementUntil scrolls the element elementJS evaluates to, for lists
+// inside modals that scroll their own container rather than the window. It
+// steps scrollStep pixels at a time until doneJS evaluates to true, the
+// content stops growing at the bottom, or maxIterations steps were taken.
+func ScrollElementUntil(cfg *config.TimingConfig, elementJS, doneJS string, scrollStep, maxIterations int) chromedp.ActionFunc {
+	scrollJS := fmt.Sprintf(`
+(() => {
+	const el = %s;
+	if (!el) return null;
+	el.scrollBy(0, %d);
+	return { height: el.scrollHeight, atBottom: el.scrollTop + el.clientHeight >= el.scrollHeight - 1 };
+})()
+`, elementJS, scrollStep)
+
+	return func(ctx context.Context) error {
+		bottomHeight := -1
+		for i := 0; i < maxIterations; i++ {
+			var done bool
+			if err := chromedp.Evaluate(doneJS, &done).Do(ctx); err != nil {
+				return fmt.Errorf("scrollElementUntil: check done: %w", err)
+			}
+			if done {
+				return nil
+			}
+
+			var state *struct {
+				Height   int  `json:"height"`
+				AtBottom bool `json:"atBottom"`
+			}
+			if err := chromedp.Evaluate(scrollJS, &state).Do(ctx); err != nil {
+				return fmt.Errorf("scrollElementUntil: scroll: %w", err)
+			}
+			if state == nil {
+				return errors.New("scrollElementUntil: scroll container not found")
+			}
+
+			wait := cfg.ScrollStepDelay
+			if state.AtBottom {
+				// at the bottom: stop if nothing new loaded since we last got here
+				if state.Height == bottomHeight {
+					return nil
+				}
+				bottomHeight = state.Height
+				wait = cfg.ScrollBottomWait
+			}
+			if err := sleepCtx(ctx, wait); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WaitVisibleUpTo waits at most timeout for sel to become visible. Running out
+// of time is not an error, so sections that are genuinely absent (e.g. the
+// rating of a new listing) don't stall extraction; cancellation of ctx is.
+func WaitVisibleUpTo(sel string, timeout time.Duration) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		err := chromedp.WaitVisible(sel, chromedp.ByQuery).Do(waitCtx)
+		if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			return nil
+		}
+		return err
+	}
+}
+
+// NavigateAndSettle navigates to url and waits for the page to settle. In
+// config.PageWaitNetworkIdle mode it returns once the main frame reports the
+// network as almost idle (at most two open connections for 500ms), or after
+// idleTimeout if that never happens; otherwise it sleeps for fixedWait.
+// A 429 answer fails it with a *RateLimitedError (see CheckRateLimit).
+func NavigateAndSettle(url string, cfg *config.TimingConfig, fixedWait time.Duration) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		if cfg.PageWaitMode != config.PageWaitNetworkIdle {
+			if err := CheckRateLimit(chromedp.Navigate(url)).Do(ctx); err != nil {
+				return err
+			}
+			return sleepCtx(ctx, fixedWait)
+		}
+
+		// a page target's id is also its main frame id
+		mainFrame := string(chromedp.FromContext(ctx).Target.TargetID)
+		idle := make(chan struct{})
+		listenCtx, stopListening := context.WithCancel(ctx)
+		defer stopListening()
+
+		// ignore lifecycle events of the previous page until this navigation starts;
+		// redirects restart the lifecycle, so idle may only be signalled once
+		started, signalled := false, false
+		chromedp.ListenTarget(listenCtx, func(ev any) {
+			e, ok := ev.(*page.EventLifecycleEvent)
+			if !ok || string(e.FrameID) != mainFrame {
+				return
+			}
+			switch e.Name {
+			case "init":
+				started = true
+			case "networkAlmostIdle":
+				if started && !signalled {
+					signalled = true
+					close(idle)
+				}
+			}
+		})
+
+		if err := CheckRateLimit(chromedp.Navigate(url)).Do(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-idle:
+			return nil
+		case <-time.After(cfg.NetworkIdleTimeout):
+			// busy pages (long polling, analytics) may never go idle
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sleepCtx waits for d, returning ctx.Err() early if the context is cancelled.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
 		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-}
\ No newline at end of file
+}
+
+// DumpHTML writes the current page's outer HTML to path, creating parent directories.
+func DumpHTML(ctx context.Context, path string) error {
+	var html string
+	if err := chromedp.Run(ctx, chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
+		return fmt.Errorf("dumpHTML: read DOM: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("dumpHTML: create dir: %w", err)
+	}
+	return os.WriteFile(path, []byte(html), 0o644)
+}
@@ -0,0 +1,88 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// LoadCookies reads a cookie jar written by SaveCookies. A missing file yields
+// no cookies and no error, and cookies that have already expired are dropped.
+func LoadCookies(path string) ([]*network.Cookie, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cookie jar: %w", err)
+	}
+
+	var cookies []*network.Cookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return nil, fmt.Errorf("parse cookie jar %s: %w", path, err)
+	}
+
+	now := time.Now()
+	return slices.DeleteFunc(cookies, func(c *network.Cookie) bool {
+		return c == nil || cookieExpired(c, now)
+	}), nil
+}
+
+// SaveCookies writes cookies to path as JSON, creating parent directories.
+func SaveCookies(path string, cookies []*network.Cookie) error {
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode cookie jar: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create cookie jar dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write cookie jar: %w", err)
+	}
+	return nil
+}
+
+// CookieParams converts browser cookies into network.SetCookies params,
+// skipping any that expired before now.
+func CookieParams(cookies []*network.Cookie, now time.Time) []*network.CookieParam {
+	params := make([]*network.CookieParam, 0, len(cookies))
+	for _, c := range cookies {
+		if cookieExpired(c, now) {
+			continue
+		}
+		p := &network.CookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+			SameSite: c.SameSite,
+		}
+		if !c.Session && c.Expires > 0 {
+			t := cdp.TimeSinceEpoch(cookieExpiry(c))
+			p.Expires = &t
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// cookieExpiry converts the cookie's epoch-seconds expiry into a time.Time.
+func cookieExpiry(c *network.Cookie) time.Time {
+	return time.Unix(0, int64(c.Expires*float64(time.Second)))
+}
+
+// cookieExpired reports whether a persistent cookie expired before now.
+// Session cookies never expire here.
+func cookieExpired(c *network.Cookie, now time.Time) bool {
+	return !c.Session && c.Expires > 0 && !cookieExpiry(c).After(now)
+}
@@ -0,0 +1,53 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ProgressReporter receives progress events during a crawl, e.g. to drive a
+// progress bar or push updates to a client. Methods are called from worker
+// goroutines, so implementations must be safe for concurrent use and should
+// not block.
+type ProgressReporter interface {
+	// OnLocationFound is called for each location page that will be crawled.
+	OnLocationFound(url string)
+	// OnURLDiscovered is called for each listing URL queued for extraction.
+	OnURLDiscovered(url string)
+	// OnPropertyScraped is called after each successful extraction with the
+	// number extracted so far and the number queued.
+	OnPropertyScraped(n, total int)
+	// OnError is called when a location or listing page fails.
+	OnError(url string, err error)
+}
+
+// NopProgress discards every event.
+type NopProgress struct{}
+
+func (NopProgress) OnLocationFound(string)     {}
+func (NopProgress) OnURLDiscovered(string)     {}
+func (NopProgress) OnPropertyScraped(int, int) {}
+func (NopProgress) OnError(string, error)      {}
+
+// StdoutProgress prints a "scraped 42/120" line per extracted listing.
+type StdoutProgress struct {
+	NopProgress
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutProgress returns a reporter writing to w, or os.Stdout if w is nil.
+func NewStdoutProgress(w io.Writer) *StdoutProgress {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &StdoutProgress{w: w}
+}
+
+func (p *StdoutProgress) OnPropertyScraped(n, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "scraped %d/%d\n", n, total)
+}
@@ -0,0 +1,17 @@
+package scraper
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStdoutProgressPrintsCounts(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewStdoutProgress(&buf)
+	p.OnURLDiscovered("https://airbnb.com/rooms/1")
+	p.OnPropertyScraped(42, 120)
+
+	if got, want := buf.String(), "scraped 42/120\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
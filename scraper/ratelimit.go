@@ -0,0 +1,263 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"scraping-airbnb/config"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// rateBackoffFactor widens the interval after each blocked request.
+	rateBackoffFactor = 2
+	// rateRelaxAfter is the success streak needed before the interval narrows again.
+	rateRelaxAfter = 5
+)
+
+// RateLimiter paces requests with a ticker. In adaptive mode, blocked requests
+// (captchas, empty pages) widen the interval multiplicatively up to a cap, and a
+// streak of successes narrows it back toward the configured rate.
+// Any number of goroutines may Wait concurrently; each tick releases one.
+type RateLimiter struct {
+	mu        sync.Mutex
+	ticker    *time.Ticker
+	tick      <-chan time.Time // ticker.C; tests swap in a channel they control
+	base      time.Duration
+	interval  time.Duration
+	max       time.Duration
+	adaptive  bool
+	successes int
+	log       *slog.Logger
+}
+
+// NewRateLimiter returns a limiter allowing rps requests per second, or nil
+// when rps <= 0 (unlimited). A nil *RateLimiter is safe to use.
+func NewRateLimiter(rps int64, adaptive bool, maxInterval time.Duration, logger *slog.Logger) *RateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	base := time.Duration(float64(time.Second) / float64(rps))
+	ticker := time.NewTicker(base)
+	return &RateLimiter{
+		ticker:   ticker,
+		tick:     ticker.C,
+		base:     base,
+		interval: base,
+		max:      max(maxInterval, base),
+		adaptive: adaptive,
+		log:      logger,
+	}
+}
+
+// Wait blocks until the next tick, or returns ctx.Err() if ctx is done first.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case <-l.tick:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Interval returns the current interval between requests.
+func (l *RateLimiter) Interval() time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.interval
+}
+
+// Blocked records a request that looked blocked and slows down in adaptive mode.
+func (l *RateLimiter) Blocked() {
+	if l == nil || !l.adaptive {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.successes = 0
+	l.setInterval(min(l.interval*rateBackoffFactor, l.max), "blocking detected; slowing down")
+}
+
+// Success records a good request; after a streak the interval relaxes toward the base rate.
+func (l *RateLimiter) Success() {
+	if l == nil || !l.adaptive {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.interval == l.base {
+		return
+	}
+	l.successes++
+	if l.successes < rateRelaxAfter {
+		return
+	}
+	l.successes = 0
+	l.setInterval(max(l.interval/rateBackoffFactor, l.base), "requests succeeding; speeding up")
+}
+
+// setInterval resets the ticker to d. Callers must hold l.mu.
+func (l *RateLimiter) setInterval(d time.Duration, msg string) {
+	if d == l.interval {
+		return
+	}
+	if l.log != nil {
+		l.log.Info(msg, "from", l.interval, "to", d)
+	}
+	l.interval = d
+	l.ticker.Reset(d)
+}
+
+// Stop releases the ticker.
+func (l *RateLimiter) Stop() {
+	if l == nil {
+		return
+	}
+	l.ticker.Stop()
+}
+
+// HostRateLimiters keeps one RateLimiter per request host, so each host is
+// paced independently at its configured rate.
+type HostRateLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*RateLimiter
+	cfg      *config.StealthConfig
+	log      *slog.Logger
+}
+
+// NewHostRateLimiters builds per-host limiters from cfg: MaxRequestsPerSecond
+// is the default and HostRequestsPerSecond holds per-host overrides.
+func NewHostRateLimiters(cfg *config.StealthConfig, logger *slog.Logger) *HostRateLimiters {
+	return &HostRateLimiters{
+		limiters: make(map[string]*RateLimiter),
+		cfg:      cfg,
+		log:      logger,
+	}
+}
+
+// For returns the limiter for rawURL's host, creating it on first use.
+// It returns nil (unlimited) when the host's rate is <= 0.
+func (h *HostRateLimiters) For(rawURL string) *RateLimiter {
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if l, ok := h.limiters[host]; ok {
+		return l
+	}
+
+	rps := h.cfg.MaxRequestsPerSecond
+	if override, ok := h.cfg.HostRequestsPerSecond[host]; ok {
+		rps = override
+	}
+	logger := h.log
+	if logger != nil {
+		logger = logger.With("host", host)
+	}
+	l := NewRateLimiter(rps, h.cfg.AdaptiveRateLimit, h.cfg.MaxRateLimitInterval, logger)
+	h.limiters[host] = l
+	return l
+}
+
+// Stop releases every limiter's ticker.
+func (h *HostRateLimiters) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, l := range h.limiters {
+		l.Stop()
+	}
+}
+
+// RateLimitedError is returned by CheckRateLimit when a page is answered with
+// 429 Too Many Requests.
+type RateLimitedError struct {
+	// RetryAfter is the wait the server asked for (0 = none given)
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited (HTTP 429), retry after %s", e.RetryAfter)
+	}
+	return "rate limited (HTTP 429)"
+}
+
+// CheckRateLimit runs navigate and fails with a *RateLimitedError if the main
+// document came back 429, carrying the Retry-After delay when one was sent.
+func CheckRateLimit(navigate chromedp.Action) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		if err := network.Enable().Do(ctx); err != nil {
+			return err
+		}
+		// a page target's id is also its main frame id
+		mainFrame := cdp.FrameID(chromedp.FromContext(ctx).Target.TargetID)
+
+		var mu sync.Mutex
+		var limited *RateLimitedError
+		listenCtx, stopListening := context.WithCancel(ctx)
+		defer stopListening()
+		chromedp.ListenTarget(listenCtx, func(ev any) {
+			e, ok := ev.(*network.EventResponseReceived)
+			if !ok || e.Type != network.ResourceTypeDocument || e.FrameID != mainFrame ||
+				e.Response.Status != http.StatusTooManyRequests {
+				return
+			}
+			mu.Lock()
+			limited = &RateLimitedError{RetryAfter: parseRetryAfter(headerValue(e.Response.Headers, "Retry-After"), time.Now())}
+			mu.Unlock()
+		})
+
+		err := navigate.Do(ctx)
+		mu.Lock()
+		defer mu.Unlock()
+		if limited != nil {
+			return limited
+		}
+		return err
+	}
+}
+
+// headerValue returns the value of the named header, matched case-insensitively.
+func headerValue(headers network.Headers, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			s, _ := v.(string)
+			return s
+		}
+	}
+	return ""
+}
+
+// parseRetryAfter reads a Retry-After value, either delay seconds or an HTTP
+// date, relative to now. Missing, malformed or past values give 0.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,162 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"scraping-airbnb/config"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAdapts(t *testing.T) {
+	l := NewRateLimiter(10, true, 700*time.Millisecond, nil)
+	defer l.Stop()
+	base := 100 * time.Millisecond
+
+	l.Blocked()
+	l.Blocked()
+	if got := l.Interval(); got != 4*base {
+		t.Fatalf("after 2 blocks interval = %v, want %v", got, 4*base)
+	}
+	l.Blocked()
+	if got := l.Interval(); got != 700*time.Millisecond {
+		t.Fatalf("interval = %v, want capped at 700ms", got)
+	}
+
+	for i := 0; i < rateRelaxAfter-1; i++ {
+		l.Success()
+	}
+	if got := l.Interval(); got != 700*time.Millisecond {
+		t.Fatalf("interval relaxed before a full success streak: %v", got)
+	}
+	l.Success()
+	if got := l.Interval(); got != 350*time.Millisecond {
+		t.Fatalf("after streak interval = %v, want 350ms", got)
+	}
+	for i := 0; i < 2*rateRelaxAfter; i++ {
+		l.Success()
+	}
+	if got := l.Interval(); got != base {
+		t.Errorf("interval = %v, want back at base %v", got, base)
+	}
+}
+
+func TestRateLimiterFixedModeIgnoresFeedback(t *testing.T) {
+	l := NewRateLimiter(10, false, time.Second, nil)
+	defer l.Stop()
+	l.Blocked()
+	if got := l.Interval(); got != 100*time.Millisecond {
+		t.Errorf("interval = %v, want unchanged 100ms", got)
+	}
+}
+
+func TestHostRateLimitersAreIndependent(t *testing.T) {
+	limiters := NewHostRateLimiters(&config.StealthConfig{
+		MaxRequestsPerSecond:  50,
+		HostRequestsPerSecond: map[string]int64{"slow.example": 1},
+	}, nil)
+	defer limiters.Stop()
+
+	slow := limiters.For("https://slow.example/rooms/1")
+	fast := limiters.For("https://fast.example/rooms/1")
+	if slow == fast {
+		t.Fatal("hosts share a limiter")
+	}
+	if limiters.For("https://slow.example/rooms/2") != slow {
+		t.Error("same host got a different limiter")
+	}
+	if got := slow.Interval(); got != time.Second {
+		t.Errorf("override interval = %v, want 1s", got)
+	}
+	if got := fast.Interval(); got != 20*time.Millisecond {
+		t.Errorf("default interval = %v, want 20ms", got)
+	}
+
+	// waiting on the slow host must not hold up the fast one
+	go slow.Wait(context.Background())
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		fast.Wait(context.Background())
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("3 fast-host requests took %v; slow host is blocking them", elapsed)
+	}
+}
+
+func TestRateLimiterWaitHonorsContext(t *testing.T) {
+	l := NewRateLimiter(1, false, 0, nil)
+	defer l.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Wait() returned after %v, want promptly after cancellation", elapsed)
+	}
+}
+
+func TestRateLimiterReleasesOneWaiterPerTick(t *testing.T) {
+	l := NewRateLimiter(50, false, 0, nil)
+	defer l.Stop()
+	tick := make(chan time.Time)
+	l.tick = tick
+
+	const workers, perWorker = 5, 4
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWorker {
+				if err := l.Wait(context.Background()); err != nil {
+					t.Errorf("Wait() error = %v", err)
+				}
+				done <- struct{}{}
+			}
+		}()
+	}
+
+	select {
+	case <-done:
+		t.Fatal("a waiter was released before any tick")
+	default:
+	}
+	// every tick is taken by one of the queued waiters and releases only it
+	for i := range workers * perWorker {
+		tick <- time.Time{}
+		<-done
+		select {
+		case <-done:
+			t.Fatalf("tick %d released more than one waiter", i+1)
+		default:
+		}
+	}
+	wg.Wait()
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 7, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"120", 2 * time.Minute},
+		{" 5 ", 5 * time.Second},
+		{"Fri, 04 Jul 2025 12:00:30 GMT", 30 * time.Second},
+		{"Fri, 04 Jul 2025 11:00:00 GMT", 0},
+		{"-3", 0},
+		{"soon", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
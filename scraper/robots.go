@@ -0,0 +1,115 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+// robotsFetchTimeout bounds fetching a single robots.txt file.
+const robotsFetchTimeout = 15 * time.Second
+
+// RobotsChecker fetches, caches, and consults robots.txt per host.
+type RobotsChecker struct {
+	client    *http.Client
+	userAgent string
+
+	mu    sync.Mutex
+	cache map[string]*robotsFetch
+}
+
+// robotsFetch is the robots.txt of one host, fetched once; done is closed
+// when data and err are set.
+type robotsFetch struct {
+	done chan struct{}
+	data *robotstxt.RobotsData
+	err  error
+}
+
+func NewRobotsChecker(userAgent string) *RobotsChecker {
+	return &RobotsChecker{
+		client:    &http.Client{Timeout: robotsFetchTimeout},
+		userAgent: userAgent,
+		cache:     make(map[string]*robotsFetch),
+	}
+}
+
+// Allowed reports whether robots.txt on rawURL's host permits fetching its path.
+// robots.txt is fetched once per host and cached for the checker's lifetime.
+func (c *RobotsChecker) Allowed(ctx context.Context, rawURL string) (bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false, fmt.Errorf("robots: parse url: %w", err)
+	}
+
+	data, err := c.robotsFor(ctx, u)
+	if err != nil {
+		return false, err
+	}
+
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return data.TestAgent(path, c.userAgent), nil
+}
+
+// robotsFor returns the robots.txt of u's host. The first caller for a host
+// fetches it without holding the lock, so other hosts aren't held up; callers
+// for the same host wait for that fetch. A failed fetch is not cached.
+func (c *RobotsChecker) robotsFor(ctx context.Context, u *url.URL) (*robotstxt.RobotsData, error) {
+	host := u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+	f, ok := c.cache[host]
+	if !ok {
+		f = &robotsFetch{done: make(chan struct{})}
+		c.cache[host] = f
+	}
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case <-f.done:
+			return f.data, f.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	f.data, f.err = c.fetch(ctx, host)
+	if f.err != nil {
+		c.mu.Lock()
+		delete(c.cache, host)
+		c.mu.Unlock()
+	}
+	close(f.done)
+	return f.data, f.err
+}
+
+// fetch downloads and parses host's robots.txt.
+func (c *RobotsChecker) fetch(ctx context.Context, host string) (*robotstxt.RobotsData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host+"/robots.txt", nil)
+	if err != nil {
+		return nil, fmt.Errorf("robots: build request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("robots: fetch %s: %w", host, err)
+	}
+	defer resp.Body.Close()
+
+	// FromResponse treats 4xx as "allow all" and 5xx as "disallow all"
+	data, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("robots: parse %s: %w", host, err)
+	}
+	return data, nil
+}
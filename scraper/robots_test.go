@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRobotsCheckerFetchesOutsideTheLock(t *testing.T) {
+	release := make(chan struct{})
+	var slowFetches atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowFetches.Add(1)
+		<-release
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nAllow: /\n")
+	}))
+	defer fast.Close()
+
+	c := NewRobotsChecker("test-agent")
+	ctx := context.Background()
+
+	// several checks queue on the slow host's robots.txt
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if allowed, err := c.Allowed(ctx, slow.URL+"/private/1"); err != nil || allowed {
+				t.Errorf("Allowed(slow /private) = %v, %v; want false, nil", allowed, err)
+			}
+		}()
+	}
+	for slowFetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// another host is answered while that fetch is still in flight
+	checked := make(chan error, 1)
+	go func() {
+		_, err := c.Allowed(ctx, fast.URL+"/rooms/1")
+		checked <- err
+	}()
+	select {
+	case err := <-checked:
+		if err != nil {
+			t.Fatalf("Allowed(fast) error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a check on another host waited for the slow robots.txt fetch")
+	}
+
+	close(release)
+	wg.Wait()
+	if n := slowFetches.Load(); n != 1 {
+		t.Errorf("slow robots.txt fetched %d times, want once", n)
+	}
+}
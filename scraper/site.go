@@ -0,0 +1,62 @@
+package scraper
+
+import "time"
+
+// SiteScraper describes everything site-specific about a crawl: where links
+// live on the start and search pages, how listing pages are read, and which
+// URLs are listings. The browser, worker pool, retry and rate-limiting
+// machinery is shared, so supporting another site means implementing this.
+type SiteScraper interface {
+	// Platform is the value stored in models.Property.Platform.
+	Platform() string
+	// DefaultSelectors returns the product-page field selectors, keyed by the
+	// config.Selector* constants, used when the config has none for a field.
+	DefaultSelectors() map[string][]string
+	// LocationLinksJS evaluates to a JSON array of {url} objects on the start
+	// page, trying selectors (anchor CSS selectors) before any built-in fallback.
+	LocationLinksJS(selectors []string) string
+	// CardLinksJS evaluates to up to limit listing URLs on a search results page.
+	CardLinksJS(limit int) string
+	// NextPageJS evaluates to the next search results page URL, or "".
+	NextPageJS() string
+	// CaptchaJS evaluates to true when the page is a bot-check challenge.
+	CaptchaJS() string
+	// ProductPage returns the scripts and selectors used on a listing page.
+	ProductPage() ProductPage
+	// IsListingURL reports whether rawURL points at a single listing.
+	IsListingURL(rawURL string) bool
+	// CanonicalURL returns the stable form of a listing URL, used to dedupe
+	// listings and as models.Property.URL. Other URLs are returned unchanged.
+	CanonicalURL(rawURL string) string
+	// ListingID extracts the site's stable listing id from rawURL, stored in
+	// models.Property.RoomID. It fails if rawURL carries no id.
+	ListingID(rawURL string) (string, error)
+	// LocalizeURL returns rawURL with the site's locale and currency query
+	// parameters set; empty values leave the corresponding parameter alone.
+	LocalizeURL(rawURL, locale, currency string) string
+	// DatedURL returns a listing URL showing the price for a stay from
+	// checkIn to checkOut.
+	DatedURL(rawURL string, checkIn, checkOut time.Time) string
+}
+
+// ProductPage holds the site-specific parts of listing page extraction.
+// Selectors are CSS selectors; the other fields are JS evaluating to a string.
+type ProductPage struct {
+	// Sections that must render before the fields inside them are read
+	TitleSection, BookingSection, LocationSection string
+	PropertyTypeJS                                string
+	CoordinatesJS                                 string
+	// ExpandDescriptionJS opens the full description and evaluates to whether
+	// it clicked anything; if it did, DescriptionModal is waited on briefly
+	// before DescriptionJS reads it
+	ExpandDescriptionJS string
+	DescriptionModal    string
+	DescriptionJS       string
+	// OpenReviewsJS opens the full review list; once ReviewsModal is visible,
+	// ReviewsScrollerJS evaluates to the element to scroll for more reviews
+	// and ReviewsJS to an array of the review texts loaded so far
+	OpenReviewsJS     string
+	ReviewsModal      string
+	ReviewsScrollerJS string
+	ReviewsJS         string
+}
@@ -0,0 +1,121 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// maskFingerprintJS hides the usual headless-Chrome tells before any page
+// script runs:
+//   - navigator.webdriver reads false instead of true
+//   - navigator.plugins and navigator.mimeTypes list the built-in PDF viewer
+//     instead of being empty
+//   - navigator.languages matches the Accept-Language header
+//   - window.chrome exists, with a runtime object, as in a normal Chrome window
+//   - the notifications permission query agrees with Notification.permission
+//     instead of reporting "denied" while the latter says "default"
+const maskFingerprintJS = `(() => {
+	const define = (obj, prop, value) =>
+		Object.defineProperty(obj, prop, { get: () => value, configurable: true });
+
+	define(Navigator.prototype, 'webdriver', false);
+
+	const languages = %s;
+	if (languages.length > 0) {
+		define(Navigator.prototype, 'languages', Object.freeze(languages));
+	}
+
+	const mime = { type: 'application/pdf', suffixes: 'pdf', description: 'Portable Document Format' };
+	const plugins = ['PDF Viewer', 'Chrome PDF Viewer', 'Chromium PDF Viewer'].map(name =>
+		({ name, filename: 'internal-pdf-viewer', description: 'Portable Document Format', length: 1, 0: mime }));
+	const list = (items, key, proto) => {
+		const l = Object.create(proto);
+		items.forEach((item, i) => { l[i] = item; });
+		define(l, 'length', items.length);
+		l.item = i => items[i] || null;
+		l.namedItem = name => items.find(item => item[key] === name) || null;
+		return l;
+	};
+	define(Navigator.prototype, 'plugins', list(plugins, 'name', PluginArray.prototype));
+	define(Navigator.prototype, 'mimeTypes', list([mime], 'type', MimeTypeArray.prototype));
+
+	if (!window.chrome) {
+		window.chrome = {};
+	}
+	if (!window.chrome.runtime) {
+		window.chrome.runtime = {};
+	}
+
+	const query = navigator.permissions && navigator.permissions.query;
+	if (query && window.Notification) {
+		navigator.permissions.query = params =>
+			params && params.name === 'notifications'
+				? Promise.resolve({ state: Notification.permission, onchange: null })
+				: query.call(navigator.permissions, params);
+	}
+})();`
+
+// MaskFingerprint makes the tab run maskFingerprintJS at document start on
+// every page it loads, with navigator.languages taken from acceptLanguage
+// (empty keeps Chrome's own). Run it once per tab, before its first
+// navigation; each run registers the script again.
+func MaskFingerprint(acceptLanguage string) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		langs, err := json.Marshal(parseAcceptLanguage(acceptLanguage))
+		if err != nil {
+			return err
+		}
+		_, err = page.AddScriptToEvaluateOnNewDocument(fmt.Sprintf(maskFingerprintJS, langs)).Do(ctx)
+		return err
+	}
+}
+
+// Viewport of a mid-sized phone, used while a tab presents a mobile user agent.
+const (
+	mobileWidth       = 390
+	mobileHeight      = 844
+	mobileScaleFactor = 3
+)
+
+// EmulateDevice makes the tab render like a phone, with a phone-sized touch
+// viewport, when mobile is set, and clears that emulation otherwise so a tab
+// switched back to a desktop user agent gets its window size again.
+func EmulateDevice(mobile bool) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		if !mobile {
+			if err := emulation.ClearDeviceMetricsOverride().Do(ctx); err != nil {
+				return err
+			}
+			return emulation.SetTouchEmulationEnabled(false).Do(ctx)
+		}
+		if err := emulation.SetDeviceMetricsOverride(mobileWidth, mobileHeight, mobileScaleFactor, true).Do(ctx); err != nil {
+			return err
+		}
+		return emulation.SetTouchEmulationEnabled(true).WithMaxTouchPoints(5).Do(ctx)
+	}
+}
+
+// IsMobileUserAgent reports whether ua is a phone browser's user agent, which
+// all carry a "Mobile" token.
+func IsMobileUserAgent(ua string) bool {
+	return strings.Contains(ua, "Mobile")
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language value
+// in order, without their q-values: "en-US,en;q=0.9" gives [en-US en].
+func parseAcceptLanguage(header string) []string {
+	languages := []string{}
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		if tag = strings.TrimSpace(tag); tag != "" && tag != "*" {
+			languages = append(languages, tag)
+		}
+	}
+	return languages
+}
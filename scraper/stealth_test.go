@@ -0,0 +1,24 @@
+package scraper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"en-US,en;q=0.9", []string{"en-US", "en"}},
+		{"fr", []string{"fr"}},
+		{" de-DE ; q=1 , *;q=0.1", []string{"de-DE"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := parseAcceptLanguage(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("parseAcceptLanguage(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// tabResetTimeout bounds the about:blank + cookie clear between uses of a tab.
+const tabResetTimeout = 5 * time.Second
+
+// Tab is a browser tab checked out of a TabPool.
+type Tab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	alloc  context.Context
+	uses   int
+}
+
+// Context returns the chromedp context of the tab. Derive per-page timeouts
+// from it; cancelling a derived context does not close the tab.
+func (t *Tab) Context() context.Context { return t.ctx }
+
+// reset blanks the tab and clears its cookies so the next page starts clean.
+func (t *Tab) reset() error {
+	ctx, cancel := context.WithTimeout(t.ctx, tabResetTimeout)
+	defer cancel()
+	return chromedp.Run(ctx, chromedp.Navigate("about:blank"), network.ClearBrowserCookies())
+}
+
+// TabPool hands out up to size tabs at once and keeps returned ones open, so
+// product pages don't pay for opening and closing a tab each. A tab is closed
+// instead of reused after maxUses pages, after a failed page, or once the
+// browser it came from has been replaced.
+type TabPool struct {
+	alloc   func() context.Context
+	maxUses int
+	setup   []chromedp.Action
+	slots   chan struct{}
+
+	mu     sync.Mutex
+	idle   []*Tab
+	closed bool
+}
+
+// NewTabPool returns a pool opening tabs from the browser returned by alloc,
+// which is called on every checkout so a recreated browser is picked up.
+// maxUses <= 1 disables reuse. setup runs once on every newly opened tab.
+func NewTabPool(alloc func() context.Context, size, maxUses int, setup ...chromedp.Action) *TabPool {
+	return &TabPool{
+		alloc:   alloc,
+		maxUses: maxUses,
+		setup:   setup,
+		slots:   make(chan struct{}, max(size, 1)),
+	}
+}
+
+// Get checks out an idle tab, or opens a new one, blocking while size tabs
+// are already checked out. Every tab must be handed back with Put.
+func (p *TabPool) Get(ctx context.Context) (*Tab, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	alloc := p.alloc()
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		t := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if t.alloc == alloc {
+			p.mu.Unlock()
+			t.uses++
+			return t, nil
+		}
+		// the browser was recreated since this tab was opened
+		t.cancel()
+	}
+	p.mu.Unlock()
+
+	tabCtx, cancel := NewTab(alloc)
+	// the first Run opens the tab; doing it on tabCtx itself ties the tab's
+	// lifetime to cancel rather than to a caller's per-page timeout
+	if err := chromedp.Run(tabCtx, p.setup...); err != nil {
+		cancel()
+		<-p.slots
+		return nil, fmt.Errorf("open tab: %w", err)
+	}
+	return &Tab{ctx: tabCtx, cancel: cancel, alloc: alloc, uses: 1}, nil
+}
+
+// Put returns t to the pool. healthy reports whether its last page succeeded;
+// unhealthy, worn-out or stale tabs, and tabs that fail to reset, are closed.
+func (p *TabPool) Put(t *Tab, healthy bool) {
+	defer func() { <-p.slots }()
+
+	if !healthy || t.uses >= p.maxUses || t.alloc != p.alloc() || t.reset() != nil {
+		t.cancel()
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		t.cancel()
+		return
+	}
+	p.idle = append(p.idle, t)
+}
+
+// Close closes every idle tab. Tabs still checked out are closed when put back.
+func (p *TabPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, t := range p.idle {
+		t.cancel()
+	}
+	p.idle = nil
+}
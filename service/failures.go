@@ -0,0 +1,46 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"scraping-airbnb/internal/domain"
+)
+
+// failureRecord is one line of the failures file.
+type failureRecord struct {
+	URL      string `json:"url"`
+	Error    string `json:"error"`
+	Attempts int    `json:"attempts"`
+}
+
+// writeFailures writes the listings that failed in se (nil when none did) to
+// Output.FailuresPath, one JSON object per line, so they can be fed back in
+// with -urls-file. Location pages that failed are left out, as they are not
+// listings. The file is rewritten even when empty, so a stale list from an
+// earlier run is never retried by mistake.
+func (s *ScraperService) writeFailures(se *domain.ScrapeError) {
+	path := s.cfg.Output.FailuresPath
+	if path == "" {
+		return
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	n := 0
+	if se != nil {
+		for _, f := range se.Failures {
+			if f.Stage != "property" {
+				continue
+			}
+			enc.Encode(failureRecord{URL: f.URL, Error: f.Err.Error(), Attempts: f.Attempts})
+			n++
+		}
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		s.log.Warn("failures file write failed", "path", path, "error", err)
+		return
+	}
+	s.log.Info("failures file written", "path", path, "failed", n)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"scraping-airbnb/internal/domain"
+	"scraping-airbnb/models"
+	"slices"
+	"sync"
+	"time"
+)
+
+// fakeScraper returns canned properties or a configured error and counts calls.
+type fakeScraper struct {
+	mu         sync.Mutex
+	properties []models.Property
+	err        error
+	calls      int
+	filter     domain.URLFilter
+	// kept is what the filter returned on the last call
+	kept []string
+}
+
+func (f *fakeScraper) Scrape(ctx context.Context, baseURL string) ([]models.Property, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.filter == nil {
+		return f.properties, f.err
+	}
+
+	urls := make([]string, len(f.properties))
+	for i, p := range f.properties {
+		urls[i] = p.URL
+	}
+	keep := f.filter(ctx, urls)
+	f.kept = keep
+	var properties []models.Property
+	for _, p := range f.properties {
+		if slices.Contains(keep, p.URL) {
+			properties = append(properties, p)
+		}
+	}
+	return properties, f.err
+}
+
+func (f *fakeScraper) ScrapeURLs(ctx context.Context, urls []string) ([]models.Property, error) {
+	properties, err := f.Scrape(ctx, "")
+	var matched []models.Property
+	for _, p := range properties {
+		if slices.Contains(urls, p.URL) {
+			matched = append(matched, p)
+		}
+	}
+	return matched, err
+}
+
+func (f *fakeScraper) ScrapeSearch(ctx context.Context, searchURL string) ([]models.Property, error) {
+	return f.Scrape(ctx, searchURL)
+}
+
+func (f *fakeScraper) SetURLFilter(filter domain.URLFilter) {
+	f.filter = filter
+}
+
+func (f *fakeScraper) SetKnownURLs(lookup domain.URLLookup) {}
+
+func (f *fakeScraper) ScrapeStream(ctx context.Context, baseURL string, out chan<- models.Property) error {
+	properties, err := f.Scrape(ctx, baseURL)
+	for _, p := range properties {
+		out <- p
+	}
+	return err
+}
+
+// fakeRepository records everything saved in memory and can be told to fail.
+type fakeRepository struct {
+	mu     sync.Mutex
+	saved  []models.Property
+	err    error
+	calls  int
+	closed bool
+}
+
+func (f *fakeRepository) Save(ctx context.Context, properties []models.Property) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	f.saved = append(f.saved, properties...)
+	return nil
+}
+
+func (f *fakeRepository) ExistingURLs(ctx context.Context, urls []string) (map[string]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing := make(map[string]bool)
+	for _, p := range f.saved {
+		if slices.Contains(urls, p.URL) {
+			existing[p.URL] = true
+		}
+	}
+	return existing, nil
+}
+
+func (f *fakeRepository) StaleURLs(ctx context.Context, before time.Time) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var urls []string
+	for _, p := range f.saved {
+		if p.ScrapedAt.Before(before) {
+			urls = append(urls, p.URL)
+		}
+	}
+	return urls, nil
+}
+
+func (f *fakeRepository) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"scraping-airbnb/config"
+	"scraping-airbnb/models"
+)
+
+// filterByPrice keeps properties whose nightly price lies within
+// [MinPrice, MaxPrice] and returns them with the number dropped. A zero bound
+// is open-ended. Listings without a price (<= 0) pass only if IncludeUnpriced is set.
+func filterByPrice(properties []models.Property, cfg *config.ScraperConfig) ([]models.Property, int) {
+	if cfg.MinPrice <= 0 && cfg.MaxPrice <= 0 && cfg.IncludeUnpriced {
+		return properties, 0
+	}
+
+	kept := make([]models.Property, 0, len(properties))
+	for _, p := range properties {
+		switch {
+		case p.Price <= 0:
+			if !cfg.IncludeUnpriced {
+				continue
+			}
+		case cfg.MinPrice > 0 && p.Price < cfg.MinPrice:
+			continue
+		case cfg.MaxPrice > 0 && p.Price > cfg.MaxPrice:
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept, len(properties) - len(kept)
+}
+
+// filterByRating keeps properties rated at or above MinRating and returns them
+// with the number dropped. Unrated listings (0) pass only if KeepUnrated is set.
+func filterByRating(properties []models.Property, cfg *config.ScraperConfig) ([]models.Property, int) {
+	if cfg.MinRating <= 0 && cfg.KeepUnrated {
+		return properties, 0
+	}
+
+	kept := make([]models.Property, 0, len(properties))
+	for _, p := range properties {
+		switch {
+		case p.Rating <= 0:
+			if !cfg.KeepUnrated {
+				continue
+			}
+		case p.Rating < cfg.MinRating:
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept, len(properties) - len(kept)
+}
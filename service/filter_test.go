@@ -0,0 +1,63 @@
+package service
+
+import (
+	"scraping-airbnb/config"
+	"scraping-airbnb/models"
+	"testing"
+)
+
+func TestFilterByPrice(t *testing.T) {
+	properties := []models.Property{
+		{URL: "cheap", Price: 40},
+		{URL: "mid", Price: 100},
+		{URL: "pricey", Price: 400},
+		{URL: "unpriced", Price: 0},
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.ScraperConfig
+		want []string
+	}{
+		{"no bounds", config.ScraperConfig{IncludeUnpriced: true}, []string{"cheap", "mid", "pricey", "unpriced"}},
+		{"range", config.ScraperConfig{MinPrice: 50, MaxPrice: 200, IncludeUnpriced: true}, []string{"mid", "unpriced"}},
+		{"min only", config.ScraperConfig{MinPrice: 100}, []string{"mid", "pricey"}},
+		{"max only", config.ScraperConfig{MaxPrice: 100}, []string{"cheap", "mid"}},
+		{"drop unpriced", config.ScraperConfig{}, []string{"cheap", "mid", "pricey"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, dropped := filterByPrice(properties, &tt.cfg)
+			if dropped != len(properties)-len(tt.want) {
+				t.Errorf("dropped = %d, want %d", dropped, len(properties)-len(tt.want))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("kept %d properties, want %v", len(got), tt.want)
+			}
+			for i, p := range got {
+				if p.URL != tt.want[i] {
+					t.Errorf("kept[%d] = %s, want %s", i, p.URL, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFilterByRating(t *testing.T) {
+	properties := []models.Property{
+		{URL: "low", Rating: 3.9},
+		{URL: "high", Rating: 4.8},
+		{URL: "unrated", Rating: 0},
+	}
+
+	got, dropped := filterByRating(properties, &config.ScraperConfig{MinRating: 4.5, KeepUnrated: true})
+	if dropped != 1 || len(got) != 2 || got[0].URL != "high" || got[1].URL != "unrated" {
+		t.Errorf("with KeepUnrated: got %v (dropped %d), want [high unrated]", got, dropped)
+	}
+
+	got, dropped = filterByRating(properties, &config.ScraperConfig{MinRating: 4.5})
+	if dropped != 2 || len(got) != 1 || got[0].URL != "high" {
+		t.Errorf("without KeepUnrated: got %v (dropped %d), want [high]", got, dropped)
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"scraping-airbnb/models"
+	"sort"
+	"strings"
+)
+
+// LocationCount is the number of listings found for one city.
+type LocationCount struct {
+	Location string `json:"location"`
+	Count    int    `json:"count"`
+}
+
+// RatedListing is a condensed listing used in the top-rated section.
+type RatedListing struct {
+	Title  string  `json:"title"`
+	Rating float32 `json:"rating"`
+	URL    string  `json:"url"`
+}
+
+// InsightsReport holds everything shown in the insights report in structured form.
+type InsightsReport struct {
+	Total               int              `json:"total"`
+	Price               PriceStats       `json:"price"`
+	MostExpensive       *models.Property `json:"most_expensive,omitempty"`
+	ListingsPerLocation []LocationCount  `json:"listings_per_location"`
+	PlatformCounts      map[string]int   `json:"platform_counts"`
+	TopRated            []RatedListing   `json:"top_rated"`
+	FilteredByPrice     int              `json:"filtered_by_price"`
+	FilteredByRating    int              `json:"filtered_by_rating"`
+}
+
+// topRatedLimit is how many listings the top-rated section includes.
+const topRatedLimit = 5
+
+func parseCity(location string) string {
+	parts := strings.Split(location, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) >= 2 {
+		// second-last part
+		return parts[len(parts)-2]
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return ""
+}
+
+// buildInsights computes the insights report for the scraped properties.
+func buildInsights(property []models.Property) InsightsReport {
+	report := InsightsReport{
+		Total:               len(property),
+		Price:               computePriceStats(property),
+		ListingsPerLocation: []LocationCount{},
+		PlatformCounts:      make(map[string]int),
+		TopRated:            []RatedListing{},
+	}
+	if len(property) == 0 {
+		return report
+	}
+
+	mostExpensive := property[0]
+	listingsPerLocation := make(map[string]int)
+
+	for _, p := range property {
+		if p.Price > mostExpensive.Price {
+			mostExpensive = p
+		}
+
+		city := parseCity(p.Location)
+		if city == "" {
+			city = p.Location
+		}
+		listingsPerLocation[city]++
+
+		report.PlatformCounts[p.Platform]++
+	}
+	report.MostExpensive = &mostExpensive
+
+	// sort locations by count desc
+	for k, v := range listingsPerLocation {
+		report.ListingsPerLocation = append(report.ListingsPerLocation, LocationCount{Location: k, Count: v})
+	}
+	sort.Slice(report.ListingsPerLocation, func(i, j int) bool {
+		return report.ListingsPerLocation[i].Count > report.ListingsPerLocation[j].Count
+	})
+
+	// top 5 highest rated
+	propertyByRating := make([]models.Property, len(property))
+	copy(propertyByRating, property)
+	sort.Slice(propertyByRating, func(i, j int) bool { return propertyByRating[i].Rating > propertyByRating[j].Rating })
+
+	limit := min(topRatedLimit, len(propertyByRating))
+	for _, p := range propertyByRating[:limit] {
+		report.TopRated = append(report.TopRated, RatedListing{Title: p.Title, Rating: p.Rating, URL: p.URL})
+	}
+
+	return report
+}
+
+// JSON returns the report as indented JSON.
+func (r InsightsReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// WriteJSON writes the report as JSON to path, replacing any existing file.
+func (r InsightsReport) WriteJSON(path string) error {
+	data, err := r.JSON()
+	if err != nil {
+		return fmt.Errorf("marshal insights: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func printInsights(report InsightsReport) {
+	if report.Total == 0 {
+		fmt.Println("No listings scraped.")
+		return
+	}
+
+	// print with clean formatting
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	fmt.Println("                    SCRAPING INSIGHTS REPORT")
+	fmt.Println(strings.Repeat("=", 60))
+
+	fmt.Println("\nSUMMARY STATISTICS")
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("  Total Listings Scraped:  %d\n", report.Total)
+	fmt.Printf("  Airbnb Listings:         %d\n", report.PlatformCounts["Airbnb"])
+	if report.FilteredByPrice > 0 {
+		fmt.Printf("  Filtered by Price:       %d\n", report.FilteredByPrice)
+	}
+	if report.FilteredByRating > 0 {
+		fmt.Printf("  Filtered by Rating:      %d\n", report.FilteredByRating)
+	}
+	fmt.Printf("  Average Price:           $%.2f\n", report.Price.Mean)
+	fmt.Printf("  Median Price:            $%.2f\n", report.Price.Median)
+	fmt.Printf("  25th-75th Percentile:    $%.2f - $%.2f\n", report.Price.P25, report.Price.P75)
+	fmt.Printf("  Std. Deviation:          $%.2f\n", report.Price.StdDev)
+	fmt.Printf("  Minimum Price:           $%.0f\n", report.Price.Min)
+	fmt.Printf("  Maximum Price:           $%.0f\n", report.Price.Max)
+
+	fmt.Println("\nMOST EXPENSIVE PROPERTY")
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("  Title:                   %s\n", report.MostExpensive.Title)
+	fmt.Printf("  Price:                   $%.0f\n", report.MostExpensive.Price)
+	fmt.Printf("  Location:                %s\n", report.MostExpensive.Location)
+
+	fmt.Println("\nLISTINGS PER LOCATION")
+	fmt.Println(strings.Repeat("-", 60))
+	for _, lc := range report.ListingsPerLocation {
+		fmt.Printf("  %-40s %d\n", lc.Location+":", lc.Count)
+	}
+
+	fmt.Println("\nTOP 5 HIGHEST RATED PROPERTIES")
+	fmt.Println(strings.Repeat("-", 60))
+	for i, p := range report.TopRated {
+		fmt.Printf("  %d. %s\n", i+1, p.Title)
+		fmt.Printf("     Rating: %.2f ⭐\n", p.Rating)
+	}
+
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Println()
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"scraping-airbnb/models"
+	"testing"
+)
+
+func TestInsightsReportWriteJSON(t *testing.T) {
+	properties := []models.Property{
+		{Title: "Loft", Platform: "Airbnb", Price: 100, Rating: 4.5, Location: "Montmartre, Paris, France", URL: "https://www.airbnb.com/rooms/1"},
+		{Title: "Villa", Platform: "Airbnb", Price: 300, Rating: 4.9, Location: "Le Marais, Paris, France", URL: "https://www.airbnb.com/rooms/2"},
+		{Title: "Flat", Platform: "Airbnb", Price: 80, Rating: 4.7, Location: "Camden, London, UK", URL: "https://www.airbnb.com/rooms/3"},
+	}
+	report := buildInsights(properties)
+	report.FilteredByPrice = 2
+
+	path := filepath.Join(t.TempDir(), "insights.json")
+	if err := report.WriteJSON(path); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got InsightsReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("insights.json is not valid JSON: %v\n%s", err, data)
+	}
+
+	if got.Total != 3 || got.FilteredByPrice != 2 || got.PlatformCounts["Airbnb"] != 3 {
+		t.Errorf("total %d, filtered by price %d, Airbnb %d; want 3, 2, 3", got.Total, got.FilteredByPrice, got.PlatformCounts["Airbnb"])
+	}
+	if got.Price.Count != 3 || got.Price.Min != 80 || got.Price.Max != 300 || got.Price.Median != 100 {
+		t.Errorf("price stats = %+v", got.Price)
+	}
+	if got.MostExpensive == nil || got.MostExpensive.Title != "Villa" {
+		t.Errorf("most expensive = %+v, want Villa", got.MostExpensive)
+	}
+	want := []LocationCount{{"Paris", 2}, {"London", 1}}
+	if len(got.ListingsPerLocation) != len(want) || got.ListingsPerLocation[0] != want[0] || got.ListingsPerLocation[1] != want[1] {
+		t.Errorf("listings per location = %v, want %v", got.ListingsPerLocation, want)
+	}
+	if len(got.TopRated) != 3 || got.TopRated[0].Title != "Villa" || got.TopRated[2].Title != "Loft" {
+		t.Errorf("top rated = %+v, want Villa, Flat, Loft", got.TopRated)
+	}
+}
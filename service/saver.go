@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"scraping-airbnb/models"
+)
+
+// batchSaver filters listings as they are scraped and saves the kept ones in
+// batches of Output.SaveEvery, so a crashed run keeps what was already saved.
+type batchSaver struct {
+	svc *ScraperService
+
+	// kept is every listing that passed the filters, for the insights report
+	kept    []models.Property
+	pending []models.Property
+	// seen drops listings sent again by a retried scrape
+	seen  map[string]bool
+	saves int
+
+	droppedByPrice  int
+	droppedByRating int
+}
+
+func newBatchSaver(svc *ScraperService) *batchSaver {
+	return &batchSaver{svc: svc, seen: make(map[string]bool)}
+}
+
+// add filters p and saves the pending batch once it is full. A batch that
+// can't be saved because ctx is done stays pending for the final flush.
+func (b *batchSaver) add(ctx context.Context, p models.Property) error {
+	if b.seen[p.URL] {
+		return nil
+	}
+	b.seen[p.URL] = true
+
+	cfg := &b.svc.cfg.Scraper
+	kept, dropped := filterByPrice([]models.Property{p}, cfg)
+	b.droppedByPrice += dropped
+	kept, dropped = filterByRating(kept, cfg)
+	b.droppedByRating += dropped
+	if len(kept) == 0 {
+		return nil
+	}
+	b.kept = append(b.kept, p)
+	b.pending = append(b.pending, p)
+
+	if every := b.svc.cfg.Output.SaveEvery; every > 0 && len(b.pending) >= every {
+		if err := b.flush(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush saves the pending listings, with retries. The repository is called
+// even with nothing pending if nothing was saved yet, so file outputs of an
+// empty run are still written.
+func (b *batchSaver) flush(ctx context.Context) error {
+	if len(b.pending) == 0 && b.saves > 0 {
+		return nil
+	}
+	err := b.svc.retryWithBackoff(ctx, func() error {
+		return b.svc.repo.Save(ctx, b.pending)
+	})
+	if err != nil {
+		return err
+	}
+	b.pending = nil
+	b.saves++
+	return nil
+}
+
+// flushOnFailure saves the listings still pending when the run fails, so a
+// failed or cancelled run keeps everything it scraped. A done ctx is swapped
+// for one bounded by partialSaveTimeout. Save errors are only logged: the
+// run's own error is the one reported.
+func (b *batchSaver) flushOnFailure(ctx context.Context) {
+	if len(b.pending) == 0 {
+		return
+	}
+	if ctx.Err() != nil {
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), partialSaveTimeout)
+		defer cancel()
+		ctx = saveCtx
+	}
+	if err := b.flush(ctx); err != nil {
+		b.svc.log.Error("saving pending listings failed", "pending", len(b.pending), "error", err)
+	}
+}
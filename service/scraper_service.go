@@ -2,64 +2,214 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"math"
+	"log/slog"
+	"math/rand"
 	"scraping-airbnb/config"
 	"scraping-airbnb/internal/domain"
 	"scraping-airbnb/models"
-	"sort"
-	"strings"
+	"scraping-airbnb/utils"
 	"time"
 )
 
+// partialSaveTimeout bounds saving what was scraped once the run's own
+// deadline has already expired.
+const partialSaveTimeout = 30 * time.Second
 
 type ScraperService struct {
 	scraper domain.Scraper
 	repo    domain.PropertyRepository
 	cfg     *config.Config
+	rng     *rand.Rand
+	log     *slog.Logger
+	// stale holds the stored listings due for a refresh, looked up once by Run
+	stale []string
 }
 
 func NewScraperService(
 	s domain.Scraper,
 	r domain.PropertyRepository,
 	cfg *config.Config,
+	logger *slog.Logger,
 ) *ScraperService {
 
-	return &ScraperService{
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	svc := &ScraperService{
 		scraper: s,
 		repo:    r,
 		cfg:     cfg,
+		rng:     rand.New(rand.NewSource(utils.Seed(cfg.Retry.JitterSeed))),
+		log:     logger.With("component", "service"),
 	}
+
+	// don't spend browser time on listings we already have, unless refreshing
+	if fs, ok := s.(domain.FilterableScraper); ok && !cfg.Scraper.Refresh {
+		fs.SetKnownURLs(r.ExistingURLs)
+		if cfg.Scraper.RefreshAfter > 0 {
+			fs.SetURLFilter(svc.refreshStale)
+		} else {
+			fs.SetURLFilter(svc.skipExisting)
+		}
+	}
+
+	return svc
 }
 
+// Run crawls from url, discovering listings through location and search
+// pages. With RefreshAfter set, stored listings due for a refresh are
+// scraped too, even if the crawl doesn't come across them.
 func (s *ScraperService) Run (ctx context.Context, url string) ([]models.Property, error) {
-	var property []models.Property
+	if s.cfg.Scraper.RefreshAfter > 0 && !s.cfg.Scraper.Refresh {
+		s.stale = s.staleURLs(ctx)
+		defer func() { s.stale = nil }()
+	}
+	return s.run(ctx, func(ctx context.Context, out chan<- models.Property) error {
+		return s.scraper.ScrapeStream(ctx, url, out)
+	})
+}
 
-	// Scrape with retries
+// RunURLs scrapes the given listing URLs directly, skipping discovery, then
+// filters, saves and reports on them like Run.
+func (s *ScraperService) RunURLs(ctx context.Context, urls []string) ([]models.Property, error) {
+	return s.run(ctx, func(ctx context.Context, out chan<- models.Property) error {
+		properties, err := s.scraper.ScrapeURLs(ctx, urls)
+		return sendAll(out, properties, err)
+	})
+}
+
+// RunSearch scrapes a single search results page, skipping homepage
+// discovery, then filters, saves and reports on the listings like Run.
+func (s *ScraperService) RunSearch(ctx context.Context, searchURL string) ([]models.Property, error) {
+	return s.run(ctx, func(ctx context.Context, out chan<- models.Property) error {
+		properties, err := s.scraper.ScrapeSearch(ctx, searchURL)
+		return sendAll(out, properties, err)
+	})
+}
+
+// sendAll sends properties to out and returns err, adapting a scrape that
+// only returns its listings at the end to run's streaming form.
+func sendAll(out chan<- models.Property, properties []models.Property, err error) error {
+	for _, p := range properties {
+		out <- p
+	}
+	return err
+}
+
+// run scrapes with retries using scrape, filtering and saving listings in
+// batches as scrape sends them, then reports on everything kept. Batches
+// saved before a failure stay saved. The repository is closed when it
+// returns, so a service serves one run.
+func (s *ScraperService) run(ctx context.Context, scrape func(context.Context, chan<- models.Property) error) ([]models.Property, error) {
+	defer s.closeRepository()
+
+	saver := newBatchSaver(s)
+
+	// Scrape with retries, saving as listings arrive
+	var partial *domain.ScrapeError
+	var saveErr error
+	deadlineHit := false
 	err := s.retryWithBackoff(ctx, func() error {
-		var scrapeErr error
-		property, scrapeErr = s.scraper.Scrape(ctx, url)
+		partial = nil
+		scrapeCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		out := make(chan models.Property)
+		done := make(chan error, 1)
+		go func() {
+			done <- scrape(scrapeCtx, out)
+			close(out)
+		}()
+		for p := range out {
+			// after a failed save, stop the scrape and drain what is in flight
+			if saveErr == nil {
+				if saveErr = saver.add(ctx, p); saveErr != nil {
+					cancel()
+				}
+			}
+		}
+		scrapeErr := <-done
+
+		if se, ok := domain.AsScrapeError(scrapeErr); ok {
+			partial = se
+		}
+		if saveErr != nil {
+			return nil
+		}
+		// out of time: keep whatever was scraped instead of failing the run
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			deadlineHit = true
+			return nil
+		}
+		// per-URL failures still yield results, so don't re-run the whole crawl for them
+		if partial != nil {
+			return nil
+		}
 		return scrapeErr
 	})
 
+	// written however the run ends, so the previous run's list never lingers
+	s.writeFailures(partial)
+
+	if saveErr != nil {
+		s.log.Error("save failed", "max_retries", s.cfg.Retry.MaxRetries, "error", saveErr)
+		return nil, saveErr
+	}
 	if err != nil {
-		log.Printf("scrape failed after %d retries: %v", s.cfg.Retry.MaxRetries, err)
+		s.log.Error("scrape failed", "max_retries", s.cfg.Retry.MaxRetries, "error", err)
+		saver.flushOnFailure(ctx)
 		return nil, err
 	}
 
-	// Save with retries
-	err = s.retryWithBackoff(ctx, func() error {
-		return s.repo.Save(ctx, property)
-	})
+	if deadlineHit {
+		s.log.Warn("run deadline reached; saving partial results", "properties", len(saver.kept))
+		// the run's context is spent, but what was scraped should still be saved
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), partialSaveTimeout)
+		defer cancel()
+		ctx = saveCtx
+	} else if partial != nil {
+		s.logScrapeFailures(partial)
+		if limit := s.cfg.Scraper.MaxFailureRatio; limit > 0 && partial.FailureRatio() > limit {
+			s.log.Error("failure ratio over threshold", "ratio", partial.FailureRatio(), "max", limit)
+			saver.flushOnFailure(ctx)
+			return nil, fmt.Errorf("%.0f%% of %d urls failed (max %.0f%%): %w",
+				partial.FailureRatio()*100, partial.Attempted, limit*100, domain.ErrTooManyFailures)
+		}
+	}
 
-	if err != nil {
-		log.Printf("save failed after %d retries: %v", s.cfg.Retry.MaxRetries, err)
+	property := saver.kept
+	if saver.droppedByPrice > 0 {
+		s.log.Info("filtered listings by price",
+			"dropped", saver.droppedByPrice, "kept", len(property),
+			"min_price", s.cfg.Scraper.MinPrice, "max_price", s.cfg.Scraper.MaxPrice)
+	}
+	if saver.droppedByRating > 0 {
+		s.log.Info("filtered listings by rating",
+			"dropped", saver.droppedByRating, "kept", len(property), "min_rating", s.cfg.Scraper.MinRating)
+	}
+
+	// Save what the last batch left pending
+	if err := saver.flush(ctx); err != nil {
+		s.log.Error("save failed", "max_retries", s.cfg.Retry.MaxRetries, "error", err)
 		return nil, err
 	}
 
 	// After successful save, print scraping insights
-	printInsights(property)
+	report := buildInsights(property)
+	report.FilteredByPrice = saver.droppedByPrice
+	report.FilteredByRating = saver.droppedByRating
+	printInsights(report)
+
+	if path := s.cfg.Output.InsightsJSONPath; path != "" {
+		if err := report.WriteJSON(path); err != nil {
+			s.log.Warn("insights report write failed", "path", path, "error", err)
+		} else {
+			s.log.Info("insights report written", "path", path)
+		}
+	}
 
 	return property, nil
 }
@@ -67,32 +217,32 @@ func (s *ScraperService) Run (ctx context.Context, url string) ([]models.Propert
 // retryWithBackoff executes fn with exponential backoff retries.
 func (s *ScraperService) retryWithBackoff(ctx context.Context, fn func() error) error {
 	maxRetries := s.cfg.Retry.MaxRetries
-	initialBackoff := s.cfg.Retry.InitialBackoff
-	maxBackoff := s.cfg.Retry.MaxBackoff
 
 	var lastErr error
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			log.Printf("[retry] attempt #%d of %d...", attempt+1, maxRetries+1)
+			s.log.Info("retrying", "attempt", attempt+1, "max_attempts", maxRetries+1)
 		}
 
 		if err := fn(); err == nil {
 			if attempt > 0 {
-				log.Printf("[retry] ✅ attempt #%d succeeded", attempt+1)
+				s.log.Info("retry succeeded", "attempt", attempt+1)
 			}
 			return nil
 		} else {
 			lastErr = err
 		}
 
+		if !domain.IsRetryable(lastErr) {
+			s.log.Warn("attempt failed permanently; not retrying", "attempt", attempt+1, "error", lastErr)
+			return fmt.Errorf("failed permanently: %w", lastErr)
+		}
+
 		if attempt < maxRetries {
-			// exponential backoff: backoff = initialBackoff * 2^attempt, capped at maxBackoff
-			backoff := time.Duration(float64(initialBackoff) * math.Pow(2, float64(attempt)))
-			if backoff > maxBackoff {
-				backoff = maxBackoff
-			}
+			// exponential backoff: backoff = initialBackoff * 2^attempt, capped at maxBackoff (jittered if enabled)
+			backoff := utils.Backoff(&s.cfg.Retry, attempt, s.rng)
 
-			log.Printf("[retry] attempt #%d failed: %v; waiting %v before retry", attempt+1, lastErr, backoff)
+			s.log.Warn("attempt failed; backing off", "attempt", attempt+1, "error", lastErr, "backoff", backoff)
 			select {
 			case <-time.After(backoff):
 				// continue to next retry
@@ -102,116 +252,79 @@ func (s *ScraperService) retryWithBackoff(ctx context.Context, fn func() error)
 		}
 	}
 
-	log.Printf("[retry] ❌ all %d attempts failed", maxRetries+1)
+	s.log.Error("all attempts failed", "attempts", maxRetries+1, "error", lastErr)
 	return fmt.Errorf("failed after %d attempts: %w", maxRetries+1, lastErr)
 }
 
-func parseCity(location string) string {
-	parts := strings.Split(location, ",")
-	for i := range parts {
-		parts[i] = strings.TrimSpace(parts[i])
+// skipExisting drops urls the repository already stores. If the lookup fails
+// the error is logged and every url is kept.
+func (s *ScraperService) skipExisting(ctx context.Context, urls []string) []string {
+	existing, err := s.repo.ExistingURLs(ctx, urls)
+	if err != nil {
+		s.log.Warn("existing url lookup failed; scraping all urls", "error", err)
+		return urls
 	}
-	if len(parts) >= 2 {
-		// second-last part
-		return parts[len(parts)-2]
+	if len(existing) == 0 {
+		return urls
 	}
-	if len(parts) == 1 {
-		return parts[0]
+
+	fresh := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if !existing[u] {
+			fresh = append(fresh, u)
+		}
 	}
-	return ""
+	s.log.Info("skipping already stored listings", "skipped", len(urls)-len(fresh))
+	return fresh
 }
 
-func printInsights(property []models.Property) {
-	total := len(property)
-	if total == 0 {
-		fmt.Println("No listings scraped.")
-		return
+// staleURLs returns the stored listings last scraped more than RefreshAfter
+// ago. If the lookup fails the error is logged and none are returned.
+func (s *ScraperService) staleURLs(ctx context.Context) []string {
+	stale, err := s.repo.StaleURLs(ctx, time.Now().Add(-s.cfg.Scraper.RefreshAfter))
+	if err != nil {
+		s.log.Warn("stale url lookup failed; scraping new urls only", "error", err)
+		return nil
 	}
+	return stale
+}
 
-	var sumPrice float64
-	minPrice := float64(property[0].Price)
-	maxPrice := float64(property[0].Price)
-	var mostExpensive models.Property
-	mostExpensive = property[0]
-
-	listingsPerLocation := make(map[string]int)
-	platformCounts := make(map[string]int)
-
-	for _, p := range property {
-		price := float64(p.Price)
-		sumPrice += price
-		if price < minPrice {
-			minPrice = price
-		}
-		if price > maxPrice {
-			maxPrice = price
-			mostExpensive = p
-		}
-
-		city := parseCity(p.Location)
-		if city == "" {
-			city = p.Location
-		}
-		listingsPerLocation[city]++
-
-		platformCounts[p.Platform]++
+// refreshStale keeps urls that are not stored yet and, during Run, adds the
+// stale listings Run looked up, so fresh listings are skipped but stale ones
+// are re-scraped even if not discovered this run.
+func (s *ScraperService) refreshStale(ctx context.Context, urls []string) []string {
+	urls = s.skipExisting(ctx, urls)
+	if len(s.stale) == 0 {
+		return urls
 	}
 
-	avgPrice := sumPrice / float64(total)
-
-	// sort locations by count desc
-	type locCount struct{
-		Loc string
-		C int
+	present := make(map[string]bool, len(urls)+len(s.stale))
+	for _, u := range urls {
+		present[u] = true
 	}
-	var locs []locCount
-	for k,v := range listingsPerLocation {
-		locs = append(locs, locCount{Loc: k, C: v})
+	added := 0
+	for _, u := range s.stale {
+		if !present[u] {
+			present[u] = true
+			urls = append(urls, u)
+			added++
+		}
 	}
-	sort.Slice(locs, func(i,j int) bool { return locs[i].C > locs[j].C })
-
-	// top 5 highest rated
-	propertyByRating := make([]models.Property, len(property))
-	copy(propertyByRating, property)
-	sort.Slice(propertyByRating, func(i,j int) bool { return propertyByRating[i].Rating > propertyByRating[j].Rating })
-
-	// print with clean formatting
-	fmt.Println("\n" + strings.Repeat("=", 60))
-	fmt.Println("                    SCRAPING INSIGHTS REPORT")
-	fmt.Println(strings.Repeat("=", 60))
-
-	fmt.Println("\nSUMMARY STATISTICS")
-	fmt.Println(strings.Repeat("-", 60))
-	fmt.Printf("  Total Listings Scraped:  %d\n", total)
-	fmt.Printf("  Airbnb Listings:         %d\n", platformCounts["Airbnb"])
-	fmt.Printf("  Average Price:           $%.2f\n", avgPrice)
-	fmt.Printf("  Minimum Price:           $%.0f\n", minPrice)
-	fmt.Printf("  Maximum Price:           $%.0f\n", maxPrice)
-
-	fmt.Println("\nMOST EXPENSIVE PROPERTY")
-	fmt.Println(strings.Repeat("-", 60))
-	fmt.Printf("  Title:                   %s\n", mostExpensive.Title)
-	fmt.Printf("  Price:                   $%.0f\n", mostExpensive.Price)
-	fmt.Printf("  Location:                %s\n", mostExpensive.Location)
+	s.log.Info("refreshing stale listings", "refresh_after", s.cfg.Scraper.RefreshAfter, "stale", added)
+	return urls
+}
 
-	fmt.Println("\nLISTINGS PER LOCATION")
-	fmt.Println(strings.Repeat("-", 60))
-	for _, lc := range locs {
-		fmt.Printf("  %-40s %d\n", lc.Loc+":", lc.C)
+// closeRepository flushes and closes the repository at the end of a run.
+func (s *ScraperService) closeRepository() {
+	if err := s.repo.Close(); err != nil {
+		s.log.Warn("failed to close repository", "error", err)
 	}
+}
 
-	fmt.Println("\nTOP 5 HIGHEST RATED PROPERTIES")
-	fmt.Println(strings.Repeat("-", 60))
-	limit := 5
-	if len(propertyByRating) < limit {
-		limit = len(propertyByRating)
-	}
-	for i := 0; i < limit; i++ {
-		p := propertyByRating[i]
-		fmt.Printf("  %d. %s\n", i+1, p.Title)
-		fmt.Printf("     Rating: %.2f ⭐\n", p.Rating)
+// logScrapeFailures reports the per-URL failures of a partially successful scrape.
+func (s *ScraperService) logScrapeFailures(se *domain.ScrapeError) {
+	s.log.Warn("scrape completed with failures", "failed", len(se.Failures), "summary", se.Error())
+	for _, f := range se.Failures {
+		s.log.Warn("url failed", "stage", f.Stage, "url", f.URL, "error", f.Err)
 	}
-
-	fmt.Println(strings.Repeat("=", 60))
-	fmt.Println()
-}
\ No newline at end of file
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"scraping-airbnb/config"
+	"scraping-airbnb/internal/domain"
+	"scraping-airbnb/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testConfig returns a config with near-instant backoff so retries don't slow tests.
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Retry.MaxRetries = 2
+	cfg.Retry.InitialBackoff = time.Millisecond
+	cfg.Retry.MaxBackoff = time.Millisecond
+	return cfg
+}
+
+// testLogger discards log output so test runs stay readable.
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func sampleProperties() []models.Property {
+	return []models.Property{
+		{Platform: "Airbnb", Title: "Loft", Price: 120, Location: "Paris, France", URL: "https://airbnb.com/rooms/1", Rating: 4.9},
+		{Platform: "Airbnb", Title: "Cabin", Price: 80, Location: "Oslo, Norway", URL: "https://airbnb.com/rooms/2", Rating: 4.5},
+	}
+}
+
+func TestRunSavesScrapedProperties(t *testing.T) {
+	scraper := &fakeScraper{properties: sampleProperties()}
+	repo := &fakeRepository{}
+
+	got, err := NewScraperService(scraper, repo, testConfig(), testLogger()).Run(context.Background(), "https://airbnb.com")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("Run() returned %d properties, want 2", len(got))
+	}
+	if len(repo.saved) != 2 {
+		t.Errorf("repository saved %d properties, want 2", len(repo.saved))
+	}
+	if scraper.calls != 1 || repo.calls != 1 {
+		t.Errorf("calls: scrape=%d save=%d, want 1 each", scraper.calls, repo.calls)
+	}
+	if !repo.closed {
+		t.Error("repository not closed after Run")
+	}
+}
+
+func TestRunSavesBatchesBeforeScrapeFails(t *testing.T) {
+	cfg := testConfig()
+	cfg.Output.SaveEvery = 1
+	scrapeErr := fmt.Errorf("browser gone: %w", domain.ErrPermanent)
+	scraper := &fakeScraper{properties: sampleProperties(), err: scrapeErr}
+	repo := &fakeRepository{}
+
+	_, err := NewScraperService(scraper, repo, cfg, testLogger()).Run(context.Background(), "https://airbnb.com")
+	if !errors.Is(err, scrapeErr) {
+		t.Fatalf("Run() error = %v, want %v", err, scrapeErr)
+	}
+	// both listings were streamed before the failure, so both are on disk
+	if len(repo.saved) != 2 || repo.calls != 2 {
+		t.Errorf("saved %d properties in %d calls, want 2 in 2", len(repo.saved), repo.calls)
+	}
+}
+
+func TestRunFailsAfterScrapeRetries(t *testing.T) {
+	cfg := testConfig()
+	scrapeErr := errors.New("browser crashed")
+	scraper := &fakeScraper{err: scrapeErr}
+	repo := &fakeRepository{}
+
+	_, err := NewScraperService(scraper, repo, cfg, testLogger()).Run(context.Background(), "https://airbnb.com")
+	if !errors.Is(err, scrapeErr) {
+		t.Fatalf("Run() error = %v, want %v", err, scrapeErr)
+	}
+	if want := cfg.Retry.MaxRetries + 1; scraper.calls != want {
+		t.Errorf("scrape called %d times, want %d", scraper.calls, want)
+	}
+	if repo.calls != 0 {
+		t.Errorf("save called %d times after failed scrape, want 0", repo.calls)
+	}
+}
+
+func TestRunSavesPendingBatchWhenScrapeFails(t *testing.T) {
+	cfg := testConfig()
+	cfg.Output.SaveEvery = 10
+	scrapeErr := errors.New("browser crashed")
+	scraper := &fakeScraper{properties: sampleProperties(), err: scrapeErr}
+	repo := &fakeRepository{}
+
+	_, err := NewScraperService(scraper, repo, cfg, testLogger()).Run(context.Background(), "https://airbnb.com")
+	if !errors.Is(err, scrapeErr) {
+		t.Fatalf("Run() error = %v, want %v", err, scrapeErr)
+	}
+	// fewer listings than a batch were scraped, so they are saved on the way out
+	if len(repo.saved) != 2 || repo.calls != 1 {
+		t.Errorf("saved %d properties in %d calls, want 2 in 1", len(repo.saved), repo.calls)
+	}
+}
+
+func TestRunSavesPendingBatchWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := testConfig()
+	cfg.Output.SaveEvery = 10
+	scraper := &fakeScraper{properties: sampleProperties(), err: context.Canceled}
+	repo := &fakeRepository{}
+
+	_, err := NewScraperService(scraper, repo, cfg, testLogger()).Run(ctx, "https://airbnb.com")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	if len(repo.saved) != 2 {
+		t.Errorf("saved %d properties, want 2", len(repo.saved))
+	}
+}
+
+func TestRunDoesNotRetryPermanentErrors(t *testing.T) {
+	scrapeErr := fmt.Errorf("listing removed: %w", domain.ErrPermanent)
+	scraper := &fakeScraper{err: scrapeErr}
+
+	_, err := NewScraperService(scraper, &fakeRepository{}, testConfig(), testLogger()).Run(context.Background(), "https://airbnb.com")
+	if !errors.Is(err, scrapeErr) {
+		t.Fatalf("Run() error = %v, want %v", err, scrapeErr)
+	}
+	if scraper.calls != 1 {
+		t.Errorf("scrape called %d times, want 1", scraper.calls)
+	}
+}
+
+func TestRunFailsAfterSaveRetries(t *testing.T) {
+	cfg := testConfig()
+	saveErr := errors.New("db unavailable")
+	scraper := &fakeScraper{properties: sampleProperties()}
+	repo := &fakeRepository{err: saveErr}
+
+	_, err := NewScraperService(scraper, repo, cfg, testLogger()).Run(context.Background(), "https://airbnb.com")
+	if !errors.Is(err, saveErr) {
+		t.Fatalf("Run() error = %v, want %v", err, saveErr)
+	}
+	if scraper.calls != 1 {
+		t.Errorf("scrape called %d times, want 1", scraper.calls)
+	}
+	if want := cfg.Retry.MaxRetries + 1; repo.calls != want {
+		t.Errorf("save called %d times, want %d", repo.calls, want)
+	}
+}
+
+func TestRunKeepsPartialResultsWithoutRetrying(t *testing.T) {
+	partial := &domain.ScrapeError{Failures: []*domain.URLError{
+		{Stage: "property", URL: "https://airbnb.com/rooms/3", Err: errors.New("timeout")},
+	}}
+	scraper := &fakeScraper{properties: sampleProperties(), err: partial}
+	repo := &fakeRepository{}
+
+	got, err := NewScraperService(scraper, repo, testConfig(), testLogger()).Run(context.Background(), "https://airbnb.com")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if scraper.calls != 1 {
+		t.Errorf("scrape called %d times, want 1", scraper.calls)
+	}
+	if len(got) != 2 || len(repo.saved) != 2 {
+		t.Errorf("got %d properties, saved %d; want 2 each", len(got), len(repo.saved))
+	}
+}
+
+func TestRunWritesFailedListingsFile(t *testing.T) {
+	partial := &domain.ScrapeError{Failures: []*domain.URLError{
+		{Stage: "cards", URL: "https://airbnb.com/s/Oslo/homes", Err: errors.New("timeout")},
+		{Stage: "property", URL: "https://airbnb.com/rooms/3", Err: errors.New("timeout"), Attempts: 3},
+	}}
+	cfg := testConfig()
+	cfg.Output.FailuresPath = filepath.Join(t.TempDir(), "failed.jsonl")
+
+	scraper := &fakeScraper{properties: sampleProperties(), err: partial}
+	if _, err := NewScraperService(scraper, &fakeRepository{}, cfg, testLogger()).Run(context.Background(), "https://airbnb.com"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(cfg.Output.FailuresPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"url":"https://airbnb.com/rooms/3","error":"timeout","attempts":3}` + "\n"
+	if string(data) != want {
+		t.Errorf("failures file = %q, want %q", data, want)
+	}
+}
+
+func TestRunClearsFailuresFileWhenScrapeFails(t *testing.T) {
+	cfg := testConfig()
+	cfg.Output.FailuresPath = filepath.Join(t.TempDir(), "failed.jsonl")
+	if err := os.WriteFile(cfg.Output.FailuresPath, []byte(`{"url":"https://airbnb.com/rooms/9"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	scraper := &fakeScraper{err: fmt.Errorf("homepage gone: %w", domain.ErrPermanent)}
+	if _, err := NewScraperService(scraper, &fakeRepository{}, cfg, testLogger()).Run(context.Background(), "https://airbnb.com"); err == nil {
+		t.Fatal("Run() error = nil, want the scrape error")
+	}
+
+	data, err := os.ReadFile(cfg.Output.FailuresPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Errorf("failures file = %q, want the previous run's list cleared", data)
+	}
+}
+
+func TestRunFailsWhenFailureRatioExceeded(t *testing.T) {
+	cfg := testConfig()
+	cfg.Scraper.MaxFailureRatio = 0.5
+	partial := &domain.ScrapeError{
+		Attempted: 3,
+		Failures: []*domain.URLError{
+			{Stage: "property", URL: "https://airbnb.com/rooms/3", Err: errors.New("timeout")},
+			{Stage: "property", URL: "https://airbnb.com/rooms/4", Err: errors.New("timeout")},
+		},
+	}
+	scraper := &fakeScraper{properties: sampleProperties()[:1], err: partial}
+	repo := &fakeRepository{}
+
+	_, err := NewScraperService(scraper, repo, cfg, testLogger()).Run(context.Background(), "https://airbnb.com")
+	if !errors.Is(err, domain.ErrTooManyFailures) {
+		t.Fatalf("Run() error = %v, want %v", err, domain.ErrTooManyFailures)
+	}
+	// the listing that was scraped is still saved
+	if len(repo.saved) != 1 || repo.calls != 1 {
+		t.Errorf("saved %d properties in %d calls, want 1 in 1", len(repo.saved), repo.calls)
+	}
+}
+
+func TestRunSkipsStoredListings(t *testing.T) {
+	stored := sampleProperties()[:1]
+	repo := &fakeRepository{saved: stored}
+	scraper := &fakeScraper{properties: sampleProperties()}
+
+	got, err := NewScraperService(scraper, repo, testConfig(), testLogger()).Run(context.Background(), "https://airbnb.com")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(got) != 1 || got[0].URL == stored[0].URL {
+		t.Errorf("Run() = %v, want only the listing not already stored", got)
+	}
+}
+
+func TestRunRefreshRescrapesStoredListings(t *testing.T) {
+	cfg := testConfig()
+	cfg.Scraper.Refresh = true
+	repo := &fakeRepository{saved: sampleProperties()[:1]}
+	scraper := &fakeScraper{properties: sampleProperties()}
+
+	got, err := NewScraperService(scraper, repo, cfg, testLogger()).Run(context.Background(), "https://airbnb.com")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("Run() returned %d properties, want 2 with Refresh", len(got))
+	}
+}
+
+func TestRunRefreshAfterRescrapesOnlyStaleListings(t *testing.T) {
+	cfg := testConfig()
+	cfg.Scraper.RefreshAfter = 24 * time.Hour
+	props := sampleProperties()
+	fresh, stale := props[0], props[1]
+	fresh.ScrapedAt = time.Now().Add(-time.Hour)
+	stale.ScrapedAt = time.Now().Add(-48 * time.Hour)
+	repo := &fakeRepository{saved: []models.Property{fresh, stale}}
+	scraper := &fakeScraper{properties: props}
+
+	got, err := NewScraperService(scraper, repo, cfg, testLogger()).Run(context.Background(), "https://airbnb.com")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(got) != 1 || got[0].URL != stale.URL {
+		t.Errorf("Run() = %v, want only the stale listing %s", got, stale.URL)
+	}
+}
+
+func TestRunURLsDoesNotAddStaleListings(t *testing.T) {
+	cfg := testConfig()
+	cfg.Scraper.RefreshAfter = 24 * time.Hour
+	props := sampleProperties()
+	stale := props[1]
+	stale.ScrapedAt = time.Now().Add(-48 * time.Hour)
+	repo := &fakeRepository{saved: []models.Property{stale}}
+	scraper := &fakeScraper{properties: props}
+
+	if _, err := NewScraperService(scraper, repo, cfg, testLogger()).RunURLs(context.Background(), []string{props[0].URL}); err != nil {
+		t.Fatalf("RunURLs() error = %v", err)
+	}
+	if slices.Contains(scraper.kept, stale.URL) {
+		t.Errorf("filter kept %v; stale listings belong to full runs only", scraper.kept)
+	}
+}
+
+func TestRunURLsScrapesOnlyGivenURLs(t *testing.T) {
+	scraper := &fakeScraper{properties: sampleProperties()}
+	repo := &fakeRepository{}
+	url := sampleProperties()[1].URL
+
+	got, err := NewScraperService(scraper, repo, testConfig(), testLogger()).RunURLs(context.Background(), []string{url})
+	if err != nil {
+		t.Fatalf("RunURLs() error = %v", err)
+	}
+	if len(got) != 1 || got[0].URL != url {
+		t.Errorf("RunURLs() = %v, want only %s", got, url)
+	}
+	if len(repo.saved) != 1 {
+		t.Errorf("repository saved %d properties, want 1", len(repo.saved))
+	}
+}
+
+func TestRunSearchSavesScrapedProperties(t *testing.T) {
+	scraper := &fakeScraper{properties: sampleProperties()}
+	repo := &fakeRepository{}
+
+	got, err := NewScraperService(scraper, repo, testConfig(), testLogger()).RunSearch(context.Background(), "https://airbnb.com/s/Paris/homes")
+	if err != nil {
+		t.Fatalf("RunSearch() error = %v", err)
+	}
+	if len(got) != 2 || len(repo.saved) != 2 {
+		t.Errorf("got %d properties, saved %d; want 2 each", len(got), len(repo.saved))
+	}
+}
+
+func TestRunSavesPartialResultsWhenDeadlineExpires(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	scraper := &fakeScraper{properties: sampleProperties()[:1], err: context.DeadlineExceeded}
+	repo := &fakeRepository{}
+
+	got, err := NewScraperService(scraper, repo, testConfig(), testLogger()).Run(ctx, "https://airbnb.com")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if scraper.calls != 1 {
+		t.Errorf("scrape called %d times, want 1", scraper.calls)
+	}
+	if len(got) != 1 || len(repo.saved) != 1 {
+		t.Errorf("got %d properties, saved %d; want 1 each", len(got), len(repo.saved))
+	}
+}
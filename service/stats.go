@@ -0,0 +1,74 @@
+package service
+
+import (
+	"math"
+	"scraping-airbnb/models"
+	"sort"
+)
+
+// PriceStats summarises the price distribution of a set of properties.
+type PriceStats struct {
+	// Count is the number of listings with a price; the others are left out
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Median float64 `json:"median"`
+	P25    float64 `json:"p25"`
+	P75    float64 `json:"p75"`
+	StdDev float64 `json:"std_dev"`
+}
+
+// computePriceStats returns price statistics for property, leaving out
+// listings without a price (<= 0) so they don't drag the figures to zero.
+// No priced listing yields the zero value; a single one has StdDev 0.
+func computePriceStats(property []models.Property) PriceStats {
+	prices := make([]float64, 0, len(property))
+	var sum float64
+	for _, p := range property {
+		if p.Price <= 0 {
+			continue
+		}
+		prices = append(prices, float64(p.Price))
+		sum += float64(p.Price)
+	}
+	n := len(prices)
+	if n == 0 {
+		return PriceStats{}
+	}
+	sort.Float64s(prices)
+
+	mean := sum / float64(n)
+
+	var sqDiff float64
+	for _, v := range prices {
+		sqDiff += (v - mean) * (v - mean)
+	}
+
+	return PriceStats{
+		Count:  n,
+		Mean:   mean,
+		Min:    prices[0],
+		Max:    prices[n-1],
+		Median: percentile(prices, 50),
+		P25:    percentile(prices, 25),
+		P75:    percentile(prices, 75),
+		StdDev: math.Sqrt(sqDiff / float64(n)),
+	}
+}
+
+// percentile returns the p-th percentile (0-100) of sorted values,
+// linearly interpolating between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	if lo == hi {
+		return sorted[lo]
+	}
+	frac := rank - float64(lo)
+	return sorted[lo] + (sorted[hi]-sorted[lo])*frac
+}
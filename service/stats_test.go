@@ -0,0 +1,41 @@
+package service
+
+import (
+	"math"
+	"scraping-airbnb/models"
+	"testing"
+)
+
+func TestComputePriceStats(t *testing.T) {
+	priced := func(prices ...float32) []models.Property {
+		properties := make([]models.Property, len(prices))
+		for i, p := range prices {
+			properties[i].Price = p
+		}
+		return properties
+	}
+
+	tests := []struct {
+		name       string
+		properties []models.Property
+		want       PriceStats
+	}{
+		{"empty", nil, PriceStats{}},
+		{"one price", priced(120), PriceStats{Count: 1, Mean: 120, Min: 120, Max: 120, Median: 120, P25: 120, P75: 120}},
+		{"even count", priced(40, 100, 60, 200), PriceStats{
+			Count: 4, Mean: 100, Min: 40, Max: 200, Median: 80, P25: 55, P75: 125, StdDev: math.Sqrt(3800),
+		}},
+		{"unpriced left out", priced(0, 100, 0, 300), PriceStats{
+			Count: 2, Mean: 200, Min: 100, Max: 300, Median: 200, P25: 150, P75: 250, StdDev: 100,
+		}},
+		{"only unpriced", priced(0, 0), PriceStats{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computePriceStats(tt.properties); got != tt.want {
+				t.Errorf("computePriceStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"scraping-airbnb/config"
+	"time"
+)
+
+// Backoff returns the wait before the retry following the given 0-based attempt:
+// InitialBackoff * 2^attempt, capped at MaxBackoff. With JitterEnabled it applies
+// full jitter, picking uniformly from [0, capped backoff] using rng.
+func Backoff(cfg *config.RetryConfig, attempt int, rng *rand.Rand) time.Duration {
+	backoff := time.Duration(float64(cfg.InitialBackoff) * math.Pow(2, float64(attempt)))
+	if backoff > cfg.MaxBackoff {
+		backoff = cfg.MaxBackoff
+	}
+	if !cfg.JitterEnabled || backoff <= 0 {
+		return backoff
+	}
+	return time.Duration(rng.Int63n(int64(backoff) + 1))
+}
+
+// Seed returns seed, or a clock-derived seed when seed is 0.
+func Seed(seed int64) int64 {
+	if seed != 0 {
+		return seed
+	}
+	return time.Now().UnixNano()
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"math/rand"
+	"scraping-airbnb/config"
+	"testing"
+	"time"
+)
+
+func TestBackoffWithoutJitter(t *testing.T) {
+	cfg := &config.RetryConfig{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	rng := rand.New(rand.NewSource(1))
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for attempt, w := range want {
+		if got := Backoff(cfg, attempt, rng); got != w {
+			t.Errorf("Backoff(attempt=%d) = %v, want %v", attempt, got, w)
+		}
+	}
+}
+
+func TestBackoffJitterStaysWithinCap(t *testing.T) {
+	cfg := &config.RetryConfig{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, JitterEnabled: true}
+	rng := rand.New(rand.NewSource(1))
+
+	for attempt := 0; attempt < 10; attempt++ {
+		for i := 0; i < 100; i++ {
+			if got := Backoff(cfg, attempt, rng); got < 0 || got > cfg.MaxBackoff {
+				t.Fatalf("Backoff(attempt=%d) = %v, want within [0, %v]", attempt, got, cfg.MaxBackoff)
+			}
+		}
+	}
+}
+
+func TestBackoffJitterIsDeterministicForSeed(t *testing.T) {
+	cfg := &config.RetryConfig{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second, JitterEnabled: true}
+	a := rand.New(rand.NewSource(42))
+	b := rand.New(rand.NewSource(42))
+
+	for attempt := 0; attempt < 5; attempt++ {
+		if x, y := Backoff(cfg, attempt, a), Backoff(cfg, attempt, b); x != y {
+			t.Errorf("attempt %d: same seed gave %v and %v", attempt, x, y)
+		}
+	}
+}
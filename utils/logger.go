@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"log/slog"
+	"os"
+	"scraping-airbnb/config"
+)
+
+// NewLogger returns a logger writing to stderr in the configured format.
+// Unknown levels fall back to info.
+func NewLogger(cfg *config.LogConfig) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	if cfg.Format == config.LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
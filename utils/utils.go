@@ -3,8 +3,13 @@ package utils
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"regexp"
+	"scraping-airbnb/models"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/chromedp/chromedp"
 )
@@ -17,28 +22,293 @@ func SafeText(sel string, val *string) chromedp.Action {
 	})
 }
 
-func ParsePrice(price string) float32 {
+// SafeEvaluate runs js into val like chromedp.Evaluate, but a script error
+// leaves val unchanged instead of failing the whole action list; val may be
+// nil when only the side effect matters. Only a done context is reported, so
+// a timed-out tab still stops extraction.
+func SafeEvaluate(js string, val *string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		var res any
+		if val != nil {
+			res = val
+		}
+		_ = chromedp.Evaluate(js, res).Do(ctx)
+		return ctx.Err()
+	})
+}
 
-	price = strings.ReplaceAll(price, "$", "")
-	price = strings.ReplaceAll(price, ",", "")
+// priceNumberRe matches the first number in a price string, including any
+// thousands/decimal separators inside it: a comma or dot, or a space-like
+// character followed by exactly three digits, so "120 2 guests" stays 120.
+var priceNumberRe = regexp.MustCompile(`\d+(?:[.,]\d+|[\s\x{00A0}\x{202F}]\d{3}\b)*`)
 
-	v, _ := strconv.ParseFloat(price, 32)
+// priceRangeSepRe matches the separator of a price range such as "$120 – $180".
+var priceRangeSepRe = regexp.MustCompile(`\s*[-\x{2013}\x{2014}]\s*|\s+to\s+`)
+
+// ParsePrice extracts the first amount from a price string such as "$1,234.56",
+// "€1.234,56" or "1 234 kr". Both US and European separator conventions are
+// supported. For a range such as "$120 – $180", shown when the price depends
+// on the dates, it returns the low end. Returns 0 when no number is present.
+func ParsePrice(price string) float32 {
+	// cut at a range separator, but not at a leading dash with no amount before it
+	if loc := priceRangeSepRe.FindStringIndex(price); loc != nil && priceNumberRe.MatchString(price[:loc[0]]) {
+		price = price[:loc[0]]
+	}
+	token := priceNumberRe.FindString(price)
+	if token == "" {
+		return 0
+	}
+
+	v, _ := strconv.ParseFloat(normalizeNumber(token), 32)
 
 	return float32(v)
 }
 
-func ParseRating(rating string) float32 {
+// normalizeNumber converts a localized number token into Go float syntax.
+// The last of '.' or ',' is the decimal separator when both appear; a lone
+// separator followed by exactly three digits is treated as a thousands separator.
+func normalizeNumber(token string) string {
+	token = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, token)
 
-	v, _ := strconv.ParseFloat(rating, 32)
+	lastDot := strings.LastIndex(token, ".")
+	lastComma := strings.LastIndex(token, ",")
 
-	return float32(v)
+	var decimal, thousands string
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot {
+			decimal, thousands = ",", "."
+		} else {
+			decimal, thousands = ".", ","
+		}
+	case lastDot >= 0 || lastComma >= 0:
+		sep := "."
+		if lastComma >= 0 {
+			sep = ","
+		}
+		if strings.Count(token, sep) > 1 || len(token)-strings.LastIndex(token, sep)-1 == 3 {
+			thousands = sep
+		} else {
+			decimal = sep
+		}
+	default:
+		return token
+	}
+
+	if thousands != "" {
+		token = strings.ReplaceAll(token, thousands, "")
+	}
+	if decimal != "" {
+		token = strings.Replace(token, decimal, ".", 1)
+	}
+	return token
+}
+
+// ratingNumberRe matches the first decimal number in a rating string.
+var ratingNumberRe = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+
+// maxRating is the top of Airbnb's rating scale.
+const maxRating = 5
+
+// ParseRating extracts the rating from text such as "4.92", " 4,8 " or
+// "4.92 · 128 reviews". Empty text and "New" listings are genuinely unrated and
+// return 0 with a nil error; any other text without a usable rating returns 0
+// and an error so callers can tell the two apart.
+func ParseRating(rating string) (float32, error) {
+	text := strings.TrimSpace(rating)
+	token := ratingNumberRe.FindString(text)
+	if token == "" {
+		if text == "" || strings.EqualFold(text, "new") {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("parse rating %q: no number found", rating)
+	}
+
+	v, err := strconv.ParseFloat(strings.Replace(token, ",", ".", 1), 32)
+	if err != nil {
+		return 0, fmt.Errorf("parse rating %q: %w", rating, err)
+	}
+	if v > maxRating {
+		return 0, fmt.Errorf("parse rating %q: %v is above %d", rating, v, maxRating)
+	}
+
+	return float32(v), nil
+}
+
+// NormalizePropertyType maps Airbnb's overview heading (e.g. "Entire rental
+// unit in Paris", "Room in boutique hotel") onto a models.PropertyType,
+// returning PropertyTypeUnknown when the text can't be classified.
+func NormalizePropertyType(text string) models.PropertyType {
+	t := strings.ToLower(strings.TrimSpace(text))
+	switch {
+	case t == "":
+		return models.PropertyTypeUnknown
+	case strings.HasPrefix(t, "entire"):
+		return models.PropertyTypeEntireHome
+	case strings.HasPrefix(t, "shared room"):
+		return models.PropertyTypeSharedRoom
+	case strings.HasPrefix(t, "hotel room"),
+		strings.HasPrefix(t, "room in") && strings.Contains(t, "hotel"):
+		return models.PropertyTypeHotelRoom
+	case strings.HasPrefix(t, "private room"), strings.HasPrefix(t, "room in"):
+		return models.PropertyTypePrivateRoom
+	default:
+		return models.PropertyTypeUnknown
+	}
+}
+
+// coordinatesRe matches a "lat,lng" pair, optionally introduced by one of the
+// parameters map URLs use to carry it (center=, ll=, q=, query= or @).
+var coordinatesRe = regexp.MustCompile(`(?:center=|ll=|q=|query=|@|^)\s*(-?\d{1,3}(?:\.\d+)?)\s*,\s*(-?\d{1,3}(?:\.\d+)?)`)
+
+// ParseCoordinates extracts latitude and longitude from a map URL such as
+// "https://maps.googleapis.com/maps/api/staticmap?center=48.85,2.35&zoom=14"
+// or from a bare "48.85,2.35" pair. It returns zero values and false when no
+// valid pair is present.
+func ParseCoordinates(text string) (lat, lng float64, ok bool) {
+	if unescaped, err := url.QueryUnescape(text); err == nil {
+		text = unescaped
+	}
+
+	m := coordinatesRe.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return 0, 0, false
+	}
+
+	lat, errLat := strconv.ParseFloat(m[1], 64)
+	lng, errLng := strconv.ParseFloat(m[2], 64)
+	if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+// zeroWidth lists invisible characters pages use for layout or tracking.
+// U+200D (zero-width joiner) is handled separately since emoji need it.
+var zeroWidth = map[rune]bool{
+	'\u00AD': true, // soft hyphen
+	'\u200B': true, // zero-width space
+	'\u200C': true, // zero-width non-joiner
+	'\u200E': true, // left-to-right mark
+	'\u200F': true, // right-to-left mark
+	'\u2060': true, // word joiner
+	'\uFEFF': true, // byte order mark
+}
+
+// CleanText tidies scraped text for storage: control and zero-width
+// characters are dropped, every run of whitespace (including newlines and
+// non-breaking spaces) becomes a single space, and the ends are trimmed.
+// Zero-width joiners are kept after symbols so emoji sequences survive.
+func CleanText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	var prev rune
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			r = ' '
+		case r == '\u200D':
+			if !unicode.Is(unicode.So, prev) && prev != '\uFE0F' {
+				continue
+			}
+		case zeroWidth[r], unicode.IsControl(r):
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Truncate shortens s to at most max runes, ending with an ellipsis when cut.
+// max <= 0 leaves s unchanged.
+func Truncate(s string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	if max == 1 {
+		return "…"
+	}
+	return strings.TrimRightFunc(string(runes[:max-1]), unicode.IsSpace) + "…"
 }
 
+// perNightPattern matches a per-night price marker: "/ night", "per night",
+// or "night" right after an amount ("$120 night") or after no number at all.
+// A stay length such as "for 4 nights" or "for 1 night" is not one.
+const perNightPattern = `/\s*night\b|\bper\s+night\b|[$€£¥]\s*\d[\d.,]*\s+night\b|(?:^|[^\d\s]\s*)night\b`
+
+// PriceLabelPattern matches the text ParsePriceType understands, a per-night
+// marker or "total"; scripts use it (case-insensitively) to find a price's label.
+const PriceLabelPattern = perNightPattern + `|total`
+
+var perNightRe = regexp.MustCompile(`(?i)` + perNightPattern)
+
+// ParsePriceType reads the label next to a price, such as "$120 night" or
+// "$480 total", and reports which kind of price it is. When both appear the
+// earlier one wins, as it is the one attached to the amount. A stay length
+// ("$480 for 4 nights") is not a per-night marker. Returns "" when the label
+// has neither.
+func ParsePriceType(label string) models.PriceType {
+	label = strings.ToLower(label)
+	night := -1
+	if loc := perNightRe.FindStringIndex(label); loc != nil {
+		night = loc[0]
+	}
+	total := strings.Index(label, "total")
+	switch {
+	case night >= 0 && (total < 0 || night < total):
+		return models.PriceTypeNightly
+	case total >= 0:
+		return models.PriceTypeTotal
+	default:
+		return ""
+	}
+}
+
+// NightlyPrice parses a price with its label and "for N nights" text. Only a
+// price labelled as a total is divided by the nights, and is then typed
+// PriceTypeNightly; a total with no nights to divide by is returned as is.
+// Any other price is returned undivided with the type of its label, which
+// is "" when the label was not recognised, so callers can tell a guess from
+// a known nightly rate.
+func NightlyPrice(priceText, labelText, nightsText string) (float32, models.PriceType) {
+	price := ParsePrice(priceText)
+	if price == 0 {
+		return 0, ""
+	}
+	priceType := ParsePriceType(labelText)
+	if nights := ParseNights(nightsText); priceType == models.PriceTypeTotal && nights > 0 {
+		return price / float32(nights), models.PriceTypeNightly
+	}
+	return price, priceType
+}
+
+// stayLengthRe matches a stay length such as "for 3 nights", "for a night" or
+// "for 2 weeks". The "for" is required: rate text such as "$120 a night" or
+// "one night minimum" is not a stay length.
+var stayLengthRe = regexp.MustCompile(`(?i)\bfor\s+(\d+|a|an|one)\s+(night|week)s?\b`)
+
+// ParseNights returns how many nights a price breakdown such as "for 3 nights"
+// or "for 1 night" covers; "night" and "nights" are accepted with any count,
+// and weeks count as 7 nights. Monthly stays ("for a month") vary in length,
+// so they return 0 like text with no stay length at all.
 func ParseNights(daysText string) int {
-	// examples:
-	// "for 3 nights"
-	// "for 1 night"
-	var nights int
-	fmt.Sscanf(daysText, "for %d night", &nights)
-	return nights
+	m := stayLengthRe.FindStringSubmatch(daysText)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		n = 1 // "a", "an", "one"
+	}
+	if strings.EqualFold(m[2], "week") {
+		n *= 7
+	}
+	return n
 }
\ No newline at end of file
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"os"
+	"scraping-airbnb/models"
+	"strings"
+	"testing"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want float32
+	}{
+		{"plain", "120", 120},
+		{"dollar sign", "$120", 120},
+		{"us thousands", "$1,234", 1234},
+		{"us thousands and decimals", "$1,234.56", 1234.56},
+		{"us decimals", "$99.99", 99.99},
+		{"european decimal comma", "€1.234,56", 1234.56},
+		{"european decimal only", "12,50 €", 12.5},
+		{"european thousands dot", "€1.234", 1234},
+		{"multiple thousands", "1,234,567", 1234567},
+		{"space thousands", "1 234 kr", 1234},
+		{"nbsp thousands", "1 234,50 €", 1234.5},
+		{"space before a guest count", "120 2 guests", 120},
+		{"space before a longer number", "120 2025", 120},
+		{"space thousands twice", "1 234 567 kr", 1234567},
+		{"surrounding whitespace", "  $250  ", 250},
+		{"trailing text", "$250 night", 250},
+		{"range with en dash", "$120 \u2013 $180", 120},
+		{"range with hyphen", "$120-$180", 120},
+		{"range with to", "$120 to $180", 120},
+		{"range with thousands", "\u20ac1.234 \u2013 \u20ac1.500", 1234},
+		{"from prefix", "From $95", 95},
+		{"thousands without decimals", "$1,200", 1200},
+		{"empty", "", 0},
+		{"no digits", "Price unavailable", 0},
+		{"currency only", "$", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParsePrice(tt.in); got != tt.want {
+				t.Errorf("ParsePrice(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRating(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    float32
+		wantErr bool
+	}{
+		{"decimal", "4.92", 4.92, false},
+		{"integer", "5", 5, false},
+		{"surrounding whitespace", "  4.85\n", 4.85, false},
+		{"with review count", "4.92 · 128 reviews", 4.92, false},
+		{"comma decimal", "4,8", 4.8, false},
+		{"empty", "", 0, false},
+		{"whitespace only", "   ", 0, false},
+		{"new listing", "New", 0, false},
+		{"new listing padded", " new ", 0, false},
+		{"malformed", "abc", 0, true},
+		{"out of range", "128 reviews", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRating(tt.in)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("ParseRating(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseNights(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want int
+	}{
+		{"singular", "for 1 night", 1},
+		{"plural", "for 3 nights", 3},
+		{"plural with one", "for 1 nights", 1},
+		{"singular with many", "for 2 night", 2},
+		{"article", "for a night", 1},
+		{"capitalized", "For 5 Nights", 5},
+		{"weeks", "for 2 weeks", 14},
+		{"month", "for a month", 0},
+		{"empty", "", 0},
+		{"no match", "per night", 0},
+		{"rate with article", "$120 a night", 0},
+		{"rate per night", "$95 per night · one night minimum", 0},
+		{"after rate", "$95 per night · $380 for 4 nights", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseNights(tt.in); got != tt.want {
+				t.Errorf("ParseNights(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePropertyType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want models.PropertyType
+	}{
+		{"Entire rental unit in Paris, France", models.PropertyTypeEntireHome},
+		{"  Entire home  ", models.PropertyTypeEntireHome},
+		{"Private room in home", models.PropertyTypePrivateRoom},
+		{"Room in bed and breakfast", models.PropertyTypePrivateRoom},
+		{"Shared room in hostel", models.PropertyTypeSharedRoom},
+		{"Hotel room", models.PropertyTypeHotelRoom},
+		{"Room in boutique hotel", models.PropertyTypeHotelRoom},
+		{"Tiny home", models.PropertyTypeUnknown},
+		{"", models.PropertyTypeUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePropertyType(tt.in); got != tt.want {
+			t.Errorf("NormalizePropertyType(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/static_map_url.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		in               string
+		wantLat, wantLng float64
+		wantOK           bool
+	}{
+		{"static map fixture", strings.TrimSpace(string(fixture)), 48.85661, 2.35222, true},
+		{"google maps link", "https://www.google.com/maps?ll=-33.8688,151.2093&z=14", -33.8688, 151.2093, true},
+		{"at sign", "https://www.google.com/maps/@40.7128,-74.006,15z", 40.7128, -74.006, true},
+		{"data attributes", "51.5072, -0.1276", 51.5072, -0.1276, true},
+		{"out of range", "center=123.4,10", 0, 0, false},
+		{"no coordinates", "https://maps.googleapis.com/maps/api/staticmap?zoom=14", 0, 0, false},
+		{"empty", "", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lng, ok := ParseCoordinates(tt.in)
+			if lat != tt.wantLat || lng != tt.wantLng || ok != tt.wantOK {
+				t.Errorf("ParseCoordinates(%q) = %v, %v, %v; want %v, %v, %v",
+					tt.in, lat, lng, ok, tt.wantLat, tt.wantLng, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"a long description", 7, "a long…"},
+		{"café au lait", 5, "café…"},
+		{"unlimited", 0, "unlimited"},
+	}
+
+	for _, tt := range tests {
+		if got := Truncate(tt.in, tt.max); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"trims", "  Cozy loft \n", "Cozy loft"},
+		{"collapses whitespace", "Cozy\n\n  loft\tin Paris", "Cozy loft in Paris"},
+		{"non-breaking spaces", "Paris,\u00a0France\u202f", "Paris, France"},
+		{"zero-width characters", "Co\u200bzy\ufeff lo\u200eft\u00ad", "Cozy loft"},
+		{"control characters", "Cozy\x00 loft\x07", "Cozy loft"},
+		{"emoji", "🌊 Beach house ☀️", "🌊 Beach house ☀️"},
+		{"emoji zwj sequence", "Family 👨\u200d👩\u200d👧 friendly", "Family 👨\u200d👩\u200d👧 friendly"},
+		{"stray joiner", "Co\u200dzy", "Cozy"},
+		{"accents and scripts", "Château près de Genève — 東京", "Château près de Genève — 東京"},
+		{"empty", " \n\u200b ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanText(tt.in); got != tt.want {
+				t.Errorf("CleanText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNightlyPrice(t *testing.T) {
+	tests := []struct {
+		name                 string
+		price, label, nights string
+		want                 float32
+		wantType             models.PriceType
+	}{
+		{"nightly label", "$120", "$120 night", "", 120, models.PriceTypeNightly},
+		{"total with nights", "$480", "$480 total", "for 4 nights", 120, models.PriceTypeNightly},
+		{"unlabelled with nights is not divided", "$480", "", "for 4 nights", 480, ""},
+		{"unrecognised label with nights is not divided", "$120", "$120 avg", "for 4 nights", 120, ""},
+		{"nightly label is not divided", "$120", "$120 night · $480 total", "for 4 nights", 120, models.PriceTypeNightly},
+		{"total without nights", "$480", "$480 total before taxes", "", 480, models.PriceTypeTotal},
+		{"stay length is not a nightly label", "$480", "$480 for 4 nights", "for 4 nights", 480, ""},
+		{"per night", "$120", "$120 per night", "for 4 nights", 120, models.PriceTypeNightly},
+		{"slash night", "€120", "€120 / night", "for 4 nights", 120, models.PriceTypeNightly},
+		{"total for one night", "$90", "$90 total", "for 1 night", 90, models.PriceTypeNightly},
+		{"unlabelled one night", "$90", "", "for 1 night", 90, ""},
+		{"unknown", "$90", "", "", 90, ""},
+		{"no price", "", "night", "for 2 nights", 0, ""},
+		{"rate text is not a stay length", "$120", "$120 total", "$120 a night", 120, models.PriceTypeTotal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotType := NightlyPrice(tt.price, tt.label, tt.nights)
+			if got != tt.want || gotType != tt.wantType {
+				t.Errorf("NightlyPrice(%q, %q, %q) = %v, %q, want %v, %q",
+					tt.price, tt.label, tt.nights, got, gotType, tt.want, tt.wantType)
+			}
+		})
+	}
+}